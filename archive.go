@@ -0,0 +1,96 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"postman-collection-migraton/converter"
+)
+
+// Extensions of the compressed collection files read besides .json files.
+const (
+	gzipExtension = ".gz"
+	zipExtension  = ".zip"
+)
+
+// maxUncompressedSize is the size a compressed collection may unpack to,
+// which keeps a malformed or hostile archive from exhausting memory.
+const maxUncompressedSize = 1 << 30
+
+// gunzip unpacks gzipped data.
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return readLimited(reader)
+}
+
+// readLimited reads r to the end, failing beyond maxUncompressedSize.
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxUncompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxUncompressedSize {
+		return nil, fmt.Errorf("uncompressed data exceeds %d bytes", maxUncompressedSize)
+	}
+	return data, nil
+}
+
+// convertZipFile converts each .json file of the zip archive into a directory
+// below outputDir, mirroring the directories it is in within the archive.
+func convertZipFile(data []byte, zipFileName, outputDir string, opts *Options, summary *Summary) []error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return []error{&converter.ParseError{Path: zipFileName, Err: err}}
+	}
+
+	var errs []error
+	usedDirNames := map[string]bool{}
+	for _, file := range archive.File {
+		// Leave out directories, metadata of macOS archivers and hidden files
+		name := path.Clean(file.Name)
+		base := path.Base(name)
+		if file.FileInfo().IsDir() || !strings.EqualFold(path.Ext(name), ".json") ||
+			strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+
+		entryName := filepath.Join(zipFileName, filepath.FromSlash(name))
+		collectionData, err := readZipFile(file)
+		if err != nil {
+			errs = append(errs, &converter.ParseError{Path: entryName, Err: err})
+			continue
+		}
+
+		// Keep entries from escaping the output directory
+		var dirs []string
+		for _, dir := range strings.Split(path.Dir(name), "/") {
+			if dir != "." && dir != ".." {
+				dirs = append(dirs, converter.SanitizeName(dir))
+			}
+		}
+		dir := filepath.Join(append(dirs, collectionDirName(base))...)
+		dir = converter.UniqueName(usedDirNames, dir)
+		errs = append(errs, convertCollectionData(collectionData, entryName, filepath.Join(outputDir, dir), opts, summary)...)
+	}
+	return errs
+}
+
+// readZipFile reads the uncompressed content of a file of a zip archive.
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return readLimited(reader)
+}
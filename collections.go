@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"postman-collection-migraton/converter"
+)
+
+// listCollectionFiles lists the collection files in the collections directory
+// tree, or the single collection file given, that match -match. There are
+// none without a collections directory.
+func listCollectionFiles(collectionsDir string, opts *Options) ([]string, error) {
+	if collectionsDir == "" {
+		return nil, nil
+	}
+	collectionFileNames, err := listFiles(collectionsDir, true, ".json", gzipExtension, zipExtension)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Match != "" {
+		collectionFileNames = matchingFileNames(collectionFileNames, opts.Match)
+	}
+	return collectionFileNames, nil
+}
+
+// convertCollectionFiles converts the collection files found in
+// collectionsDir into outputDir, running opts.Jobs conversions in parallel,
+// and returns what was converted.
+func convertCollectionFiles(collectionFileNames []string, collectionsDir, outputDir string, opts *Options) *Summary {
+	summary := &Summary{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Clear the output of previous runs up front, the output directory of a
+	// collection may contain the one of another collection
+	if opts.Clean {
+		for _, collectionFileName := range collectionFileNames {
+			dir := collectionOutputDir(collectionFileName, collectionsDir, outputDir)
+			for _, err := range removeOutput(dir, opts) {
+				logConversionError(err)
+				summary.Errors++
+			}
+		}
+	}
+
+	progress.start(len(collectionFileNames), opts.Progress)
+	defer progress.finish()
+
+	collectionFileNamesCh := make(chan string)
+	for i := 0; i < max(opts.Jobs, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for collectionFileName := range collectionFileNamesCh {
+				collectionOutputDir := collectionOutputDir(collectionFileName, collectionsDir, outputDir)
+
+				// Count per collection, so that workers only share the total
+				collectionSummary := &Summary{}
+				errs := convertCollectionFile(collectionFileName, collectionOutputDir, opts, collectionSummary)
+				for _, err := range errs {
+					logConversionError(err)
+				}
+				if len(errs) > 0 && collectionSummary.Requests > 0 {
+					logErrorf("Converted collection %s with %d errors", filepath.Base(collectionFileName), len(errs))
+				}
+				// List what was written for the next run to clean up
+				if len(collectionSummary.Written) > 0 {
+					if err := writeOutputList(collectionOutputDir, collectionSummary.Written, opts); err != nil {
+						logConversionError(err)
+						errs = append(errs, err)
+					}
+				}
+				collectionSummary.Errors += len(errs)
+
+				mu.Lock()
+				summary.Add(collectionSummary)
+				mu.Unlock()
+				progress.increment()
+			}
+		}()
+	}
+
+	for _, collectionFileName := range collectionFileNames {
+		collectionFileNamesCh <- collectionFileName
+	}
+	close(collectionFileNamesCh)
+	wg.Wait()
+
+	return summary
+}
+
+// collectionOutputDir returns the directory the collection file is
+// converted into, mirroring the directories it is nested in below
+// collectionsDir.
+func collectionOutputDir(collectionFileName, collectionsDir, outputDir string) string {
+	var nestedDir string
+	if relativeName, err := filepath.Rel(collectionsDir, collectionFileName); err == nil {
+		nestedDir = filepath.Dir(relativeName)
+	}
+	return filepath.Join(outputDir, nestedDir, collectionDirName(filepath.Base(collectionFileName)))
+}
+
+// collectionDirName returns the directory name for a collection file, its
+// name without the extensions of Postman exports and of compression.
+func collectionDirName(fileName string) string {
+	for _, extension := range []string{gzipExtension, zipExtension} {
+		if strings.EqualFold(filepath.Ext(fileName), extension) {
+			fileName = fileName[:len(fileName)-len(extension)]
+		}
+	}
+	return converter.SanitizeName(strings.TrimSuffix(fileName, ".postman_collection.json"))
+}
+
+// convertCollectionFile converts the collection file into outputDir, or each
+// collection of a workspace export or zip archive into a directory of its own
+// below it. It counts what it converted in summary and returns what went
+// wrong.
+func convertCollectionFile(collectionFileName, outputDir string, opts *Options, summary *Summary) []error {
+	// Read the Postman Collection 2.1 JSON file
+	collectionData, err := os.ReadFile(collectionFileName)
+	if err != nil {
+		return []error{&converter.ParseError{Path: collectionFileName, Err: err}}
+	}
+
+	// Unpack compressed exports
+	switch strings.ToLower(filepath.Ext(collectionFileName)) {
+	case gzipExtension:
+		if collectionData, err = gunzip(collectionData); err != nil {
+			return []error{&converter.ParseError{Path: collectionFileName, Err: err}}
+		}
+	case zipExtension:
+		return convertZipFile(collectionData, collectionFileName, outputDir, opts, summary)
+	}
+	return convertCollectionData(collectionData, collectionFileName, outputDir, opts, summary)
+}
+
+// convertCollectionData converts the collection read from path into
+// outputDir, or each collection of a workspace export into a directory of its
+// own below it.
+func convertCollectionData(collectionData []byte, collectionFileName, outputDir string, opts *Options, summary *Summary) []error {
+	embedded := converter.WorkspaceCollections(collectionData)
+	if embedded == nil {
+		return convertCollection(collectionData, collectionFileName, outputDir, opts, summary)
+	}
+
+	var errs []error
+	usedDirNames := map[string]bool{}
+	for i, collectionData := range embedded {
+		path := fmt.Sprintf("%s (collection %d)", collectionFileName, i+1)
+		var collection struct {
+			Info converter.Info `json:"info"`
+		}
+		dirName := fmt.Sprintf("collection-%d", i+1)
+		if err := json.Unmarshal(collectionData, &collection); err == nil && collection.Info.Name != "" {
+			path = fmt.Sprintf("%s (%s)", collectionFileName, collection.Info.Name)
+			dirName = converter.SanitizeName(collection.Info.Name)
+		}
+		errs = append(errs, convertCollection(collectionData, path, filepath.Join(outputDir, converter.UniqueName(usedDirNames, dirName)), opts, summary)...)
+	}
+	return errs
+}
+
+// convertCollection converts the collection read from path into outputDir,
+// counts what it converted in summary, the collection only when all of it
+// could be converted, and returns what went wrong.
+func convertCollection(collectionData []byte, path, outputDir string, opts *Options, summary *Summary) []error {
+	// Parse the JSON data
+	collection, err := converter.ParseCollection(collectionData)
+	if err != nil {
+		return []error{withPath(err, path)}
+	}
+
+	// Leave no empty directory behind for a collection without requests
+	if !converter.HasRequests(collection.Items, &opts.Options) {
+		logWarnf("Warning: collection %s has no requests to convert, skipping it", path)
+		return nil
+	}
+
+	// Lay the collection out into files
+	converted, errs := converter.ConvertCollectionFiles(collection, filepath.Base(outputDir), &opts.Options)
+	for i, err := range errs {
+		errs[i] = withPath(err, path)
+	}
+	for _, request := range converted.Requests {
+		logInfof("Converted request %s", request.Name)
+	}
+	summary.Requests += len(converted.Requests)
+	summary.Folders += converted.Folders
+
+	// Catch the variables no environment will provide before httpYac does
+	if opts.DefinedVariables != nil {
+		for _, err := range converter.UndefinedVariables(collection, opts.DefinedVariables, &opts.Options) {
+			if opts.StrictVariables {
+				errs = append(errs, withPath(err, path))
+			} else {
+				logWarnf("Warning: request %s of %s: %v", err.Request, path, err.Err)
+			}
+		}
+	}
+
+	// Save the converted collection
+	written, writeErrs := writeOutputFiles(outputDir, path, converted.Files, opts)
+	summary.Written = append(summary.Written, written...)
+	errs = append(errs, writeErrs...)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	logInfof("Converted collection: %s", path)
+	summary.Collections++
+	return nil
+}
+
+// withPath sets the file the error is about on the parse and convert errors
+// of the converter, which only sees the data of the file.
+func withPath(err error, path string) error {
+	var parseErr *converter.ParseError
+	var convertErr *converter.ConvertError
+	switch {
+	case errors.As(err, &parseErr) && parseErr.Path == "":
+		parseErr.Path = path
+	case errors.As(err, &convertErr) && convertErr.Path == "":
+		convertErr.Path = path
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"postman-collection-migraton/converter"
+)
+
+const fileSystemCollection = `{
+	"info": {"name": "Users API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"variable": [{"key": "baseUrl", "value": "https://api.example.com"}],
+	"item": [
+		{"name": "Get", "request": {"method": "GET", "url": "{{baseUrl}}/users"}},
+		{"name": "Admin", "item": [
+			{"name": "Delete", "request": {"method": "DELETE", "url": "{{baseUrl}}/users/1"}}
+		]}
+	]
+}`
+
+func TestConvertCollectionFilesToFileSystem(t *testing.T) {
+	collectionFileName := filepath.Join(t.TempDir(), "users.postman_collection.json")
+	if err := os.WriteFile(collectionFileName, []byte(fileSystemCollection), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  *Options
+		files map[string]string
+	}{
+		{
+			name: "file per request",
+			opts: &Options{},
+			files: map[string]string{
+				"out/users/_collection.http":         "# Users API\n#\n# Converted from https://schema.getpostman.com/json/collection/v2.1.0/collection.json\n",
+				"out/users/Get.http":                 "# @name get\nGET {{baseUrl}}/users\n",
+				"out/users/Admin/Delete.http":        "# @name delete\nDELETE {{baseUrl}}/users/1\n",
+				"out/users/.env":                     "baseUrl=https://api.example.com\n",
+				"out/users/.postman-to-httpyac.json": "{\n  \"files\": [\n    \".env\",\n    \"Admin/Delete.http\",\n    \"Get.http\",\n    \"_collection.http\"\n  ]\n}\n",
+			},
+		},
+		{
+			name: "single file",
+			opts: &Options{Options: converter.Options{SingleFile: true}},
+			files: map[string]string{
+				"out/users/_collection.http":         "# Users API\n#\n# Converted from https://schema.getpostman.com/json/collection/v2.1.0/collection.json\n",
+				"out/users/users.http":               "# @name get\nGET {{baseUrl}}/users\n",
+				"out/users/Admin/Admin.http":         "# ###### Admin ######\n\n# @name delete\nDELETE {{baseUrl}}/users/1\n",
+				"out/users/.env":                     "baseUrl=https://api.example.com\n",
+				"out/users/.postman-to-httpyac.json": "{\n  \"files\": [\n    \".env\",\n    \"Admin/Admin.http\",\n    \"_collection.http\",\n    \"users.http\"\n  ]\n}\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newMemFileSystem()
+			tt.opts.FS = fsys
+			summary := convertCollectionFiles([]string{collectionFileName}, filepath.Dir(collectionFileName), "out", tt.opts)
+			if summary.Errors > 0 || summary.Collections != 1 || summary.Requests != 2 || summary.Folders != 1 {
+				t.Errorf("convertCollectionFiles() summary = %+v", summary)
+			}
+
+			var paths []string
+			for name := range fsys.Files {
+				paths = append(paths, filepath.ToSlash(name))
+			}
+			slices.Sort(paths)
+			var wantPaths []string
+			for name := range tt.files {
+				wantPaths = append(wantPaths, name)
+			}
+			slices.Sort(wantPaths)
+			if !slices.Equal(paths, wantPaths) {
+				t.Fatalf("written files = %v, want %v", paths, wantPaths)
+			}
+			for name, want := range tt.files {
+				if got := string(fsys.Files[filepath.FromSlash(name)]); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
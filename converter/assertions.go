@@ -0,0 +1,151 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// e.g. pm.test("Status code is 200", function () {
+	testOpenPattern = regexp.MustCompile(`^pm\.test\(\s*(?:"[^"]*"|'[^']*')\s*,\s*(?:function\s*\(\s*\)|\(\s*\)\s*=>)\s*\{$`)
+	// e.g. });
+	testClosePattern = regexp.MustCompile(`^\}\s*\)$`)
+	// e.g. pm.response.to.have.status(200)
+	statusAssertionPattern = regexp.MustCompile(`^pm\.response\.to\.(?:have|be)\.status\(\s*(\d{3})\s*\)$`)
+	// e.g. pm.response.to.have.header("Content-Type")
+	headerAssertionPattern = regexp.MustCompile(`^pm\.response\.to\.have\.header\(\s*(?:"([^"]+)"|'([^']+)')\s*\)$`)
+	// e.g. pm.expect(jsonData.id).to.eql(5)
+	expectPattern = regexp.MustCompile(`^pm\.expect\((.+?)\)\.to\.((?:(?:be|have|not|deep)\.)*)(\w+)(?:\(\s*(.*?)\s*\))?$`)
+	// e.g. pm.response.headers.get("Content-Type")
+	responseHeaderPattern = regexp.MustCompile(`^pm\.response\.headers\.get\(\s*(?:"([^"]+)"|'([^']+)')\s*\)$`)
+)
+
+// expectOperators maps chai assertions taking a value to the httpYac
+// assertion predicates.
+var expectOperators = map[string]string{
+	"eql":         "==",
+	"equal":       "==",
+	"equals":      "==",
+	"eq":          "==",
+	"include":     "includes",
+	"includes":    "includes",
+	"contain":     "includes",
+	"contains":    "includes",
+	"above":       ">",
+	"greaterThan": ">",
+	"gt":          ">",
+	"least":       ">=",
+	"gte":         ">=",
+	"below":       "<",
+	"lessThan":    "<",
+	"lt":          "<",
+	"most":        "<=",
+	"lte":         "<=",
+}
+
+// expectPredicates maps chai assertions without a value to the httpYac
+// assertion predicates.
+var expectPredicates = map[string]string{
+	"exist": "exists",
+	"ok":    "exists",
+	"true":  "isTrue",
+	"false": "isFalse",
+}
+
+// expectTypes maps the types of chai's a() and an() to the httpYac type
+// predicates.
+var expectTypes = map[string]string{
+	"string":  "isString",
+	"number":  "isNumber",
+	"boolean": "isBoolean",
+	"array":   "isArray",
+}
+
+// translateAssertion translates a Postman assertion on the status, headers,
+// response time or JSON body into an httpYac assertion, without its leading
+// "??".
+func translateAssertion(statement string, aliases map[string]bool) (string, bool) {
+	if match := statusAssertionPattern.FindStringSubmatch(statement); match != nil {
+		return "status == " + match[1], true
+	}
+	if match := headerAssertionPattern.FindStringSubmatch(statement); match != nil {
+		return fmt.Sprintf("header %s exists", match[1]+match[2]), true
+	}
+
+	match := expectPattern.FindStringSubmatch(statement)
+	if match == nil {
+		return "", false
+	}
+	subject, ok := assertionSubject(strings.TrimSpace(match[1]), aliases)
+	if !ok {
+		return "", false
+	}
+	negated := strings.Contains(match[2], "not.")
+	assertion, argument := match[3], match[4]
+
+	if operator, ok := expectOperators[assertion]; ok && argument != "" {
+		value, ok := assertionValue(argument)
+		if !ok {
+			return "", false
+		}
+		if negated {
+			if operator != "==" {
+				return "", false
+			}
+			operator = "!="
+		}
+		return fmt.Sprintf("%s %s %s", subject, operator, value), true
+	}
+	if negated {
+		return "", false
+	}
+	if predicate, ok := expectPredicates[assertion]; ok && argument == "" {
+		return fmt.Sprintf("%s %s", subject, predicate), true
+	}
+	if assertion == "a" || assertion == "an" {
+		typeName, ok := assertionValue(argument)
+		if predicate, known := expectTypes[typeName]; ok && known {
+			return fmt.Sprintf("%s %s", subject, predicate), true
+		}
+	}
+	return "", false
+}
+
+// assertionSubject translates what a Postman assertion is about into the
+// httpYac assertion subject.
+func assertionSubject(expression string, aliases map[string]bool) (string, bool) {
+	switch expression {
+	case "pm.response.code":
+		return "status", true
+	case "pm.response.responseTime":
+		return "duration", true
+	}
+	if match := responseHeaderPattern.FindStringSubmatch(expression); match != nil {
+		return "header " + match[1] + match[2], true
+	}
+	if expression, ok := responseExpression(expression, aliases); ok {
+		return "js " + expression, true
+	}
+	return "", false
+}
+
+// assertionValue returns a JavaScript literal the way httpYac expects it in
+// an assertion, strings without their quotes.
+func assertionValue(literal string) (string, bool) {
+	switch {
+	case len(literal) >= 2 && (literal[0] == '"' || literal[0] == '\'') && literal[len(literal)-1] == literal[0]:
+		value := literal[1 : len(literal)-1]
+		if strings.ContainsAny(value, `"'\`) {
+			return "", false
+		}
+		return value, true
+	case literal == "true" || literal == "false" || literal == "null":
+		return literal, true
+	}
+	if _, err := strconv.ParseFloat(literal, 64); err == nil {
+		return literal, true
+	}
+	return "", false
+}
@@ -0,0 +1,421 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// appliedAuth -
+type appliedAuth struct {
+	Headers []*Header
+	// Query parameters to add to the URL for auth through the query string
+	Query []*QueryParam
+	// httpYac variables to define for the request, e.g. OAuth2 settings
+	Variables []*httpYacVariable
+	// Comments for the request about auth httpYac can't apply itself
+	Notes []string
+	// httpYac script blocks applying the auth, e.g. signing the request
+	Scripts []string
+}
+
+// httpYacVariable -
+type httpYacVariable struct {
+	Name  string
+	Value string
+}
+
+// oauth2GrantTypes maps Postman OAuth2 grant types to the ones httpYac
+// names in "Authorization: oauth2 <grant type>".
+var oauth2GrantTypes = map[string]string{
+	"client_credentials":           "client_credentials",
+	"authorization_code":           "authorization_code",
+	"authorization_code_with_pkce": "authorization_code",
+	"password_credentials":         "password",
+	"implicit":                     "implicit",
+}
+
+// oauth2Variables maps Postman OAuth2 attributes to the variables httpYac
+// reads the OAuth2 flow settings from.
+var oauth2Variables = []struct {
+	attribute string
+	variable  string
+}{
+	{"accessTokenUrl", "oauth2_tokenEndpoint"},
+	{"authUrl", "oauth2_authorizationEndpoint"},
+	{"clientId", "oauth2_clientId"},
+	{"clientSecret", "oauth2_clientSecret"},
+	{"scope", "oauth2_scope"},
+	{"redirect_uri", "oauth2_redirectUri"},
+	{"username", "oauth2_username"},
+	{"password", "oauth2_password"},
+	{"audience", "oauth2_audience"},
+	{"resource", "oauth2_resource"},
+}
+
+// applyAuth adds what is needed to authenticate the request the way the
+// Postman auth block describes. Headers set explicitly on the request always
+// win over the ones derived from the auth block.
+func applyAuth(auth *Auth, headers []*Header, opts *Options) *appliedAuth {
+	applied := &appliedAuth{Headers: headers}
+	if auth == nil {
+		return applied
+	}
+
+	switch auth.Type {
+	case "bearer":
+		token := authAttribute(auth.Bearer, "token")
+		if token != "" {
+			applied.Headers = withDefaultHeader(headers, "Authorization", "Bearer "+token)
+		}
+	case "basic":
+		// httpYac base64-encodes "Basic user:password" itself, which keeps
+		// {{variable}} references resolvable at request time.
+		username := authAttribute(auth.Basic, "username")
+		password := authAttribute(auth.Basic, "password")
+		if username != "" || password != "" {
+			applied.Headers = withDefaultHeader(headers, "Authorization", fmt.Sprintf("Basic %s:%s", username, password))
+		}
+	case "apikey":
+		key := authAttribute(auth.APIKey, "key")
+		value := authAttribute(auth.APIKey, "value")
+		if key == "" {
+			break
+		}
+		// Postman adds the key as a header unless told otherwise
+		if authAttribute(auth.APIKey, "in") == "query" {
+			applied.Query = []*QueryParam{{Key: key, Value: value}}
+		} else {
+			applied.Headers = withDefaultHeader(headers, key, value)
+		}
+	case "oauth2":
+		applyOAuth2(auth.OAuth2, applied, opts)
+	case "digest":
+		// httpYac answers the digest challenge itself given the credentials
+		username := authAttribute(auth.Digest, "username")
+		password := authAttribute(auth.Digest, "password")
+		if username != "" || password != "" {
+			applied.Headers = withDefaultHeader(headers, "Authorization", fmt.Sprintf("Digest %s %s", username, password))
+		}
+	case "ntlm":
+		keepAuthVariables("NTLM", "ntlm_", ntlmVariables, auth.NTLM, applied, opts)
+	case "oauth1":
+		applyOAuth1(auth.OAuth1, applied, opts)
+	case "hawk":
+		keepAuthVariables("Hawk", "hawk_", hawkVariables, auth.Hawk, applied, opts)
+	case "edgegrid":
+		keepAuthVariables("Akamai EdgeGrid", "edgegrid_", edgeGridVariables, auth.EdgeGrid, applied, opts)
+	case "awsv4":
+		applyAWSv4(auth.AWSv4, applied)
+	}
+	return applied
+}
+
+// applyOAuth2 lets httpYac fetch the token itself, configuring the flow
+// through its oauth2_ variables.
+func applyOAuth2(attributes AuthAttributes, applied *appliedAuth, opts *Options) {
+	if findHeader(applied.Headers, "Authorization") != nil {
+		return
+	}
+
+	postmanGrantType := authAttribute(attributes, "grant_type")
+	if postmanGrantType == "" {
+		postmanGrantType = "authorization_code"
+	}
+	grantType, ok := oauth2GrantTypes[postmanGrantType]
+	if !ok {
+		opts.warnf("Warning: OAuth2 grant type %s has no httpYac equivalent", postmanGrantType)
+		return
+	}
+
+	for _, mapping := range oauth2Variables {
+		if value := authAttribute(attributes, mapping.attribute); value != "" {
+			applied.Variables = append(applied.Variables, &httpYacVariable{Name: mapping.variable, Value: value})
+		}
+	}
+	if postmanGrantType == "authorization_code_with_pkce" {
+		applied.Variables = append(applied.Variables, &httpYacVariable{Name: "oauth2_usePkce", Value: "true"})
+	}
+	applied.Headers = withDefaultHeader(applied.Headers, "Authorization", "oauth2 "+grantType)
+}
+
+// ntlmVariables are the Postman NTLM attributes, kept as variables of the
+// same name prefixed with ntlm_.
+var ntlmVariables = []string{"username", "password", "domain", "workstation"}
+
+// hawkVariables are the Postman Hawk attributes, kept as variables of the
+// same name prefixed with hawk_.
+var hawkVariables = []string{"authId", "authKey", "algorithm", "user", "nonce", "extraData", "app", "delegation", "timestamp", "includePayloadHash"}
+
+// edgeGridVariables are the Postman Akamai EdgeGrid attributes, kept as
+// variables of the same name prefixed with edgegrid_.
+var edgeGridVariables = []string{"accessToken", "clientToken", "clientSecret", "nonce", "timestamp", "baseURL", "headersToSign"}
+
+// keepAuthVariables keeps the attributes of auth httpYac can't apply itself
+// as variables named prefix + attribute, and explains in a note where they
+// went, so the auth can be configured by hand.
+func keepAuthVariables(name, prefix string, variables []string, attributes AuthAttributes, applied *appliedAuth, opts *Options) {
+	applied.Variables = append(applied.Variables, authVariables(prefix, variables, attributes)...)
+	applied.Notes = append(applied.Notes, fmt.Sprintf("Postman used %s auth, which httpYac does not support natively. The credentials are kept in the %s variables.", name, prefix))
+	opts.warnf("Warning: %s auth has no httpYac equivalent, keeping the credentials as variables", name)
+}
+
+// authVariables returns the attributes set among variables as variables
+// named prefix + attribute.
+func authVariables(prefix string, variables []string, attributes AuthAttributes) []*httpYacVariable {
+	var kept []*httpYacVariable
+	for _, attribute := range variables {
+		if value := authAttribute(attributes, attribute); value != "" {
+			kept = append(kept, &httpYacVariable{Name: prefix + attribute, Value: value})
+		}
+	}
+	return kept
+}
+
+// oauth1Variables are the Postman OAuth1 credentials and parameters, kept
+// as variables of the same name prefixed with oauth1_ for the signing
+// script, or for configuring the signature by hand.
+var oauth1Variables = []string{"consumerKey", "consumerSecret", "token", "tokenSecret", "timestamp", "nonce", "version", "realm", "callback", "verifier"}
+
+// oauth1Hashes maps the OAuth1 HMAC signature methods to the Node.js hash
+// the signing script computes them with.
+var oauth1Hashes = map[string]string{
+	"HMAC-SHA1":   "sha1",
+	"HMAC-SHA256": "sha256",
+	"HMAC-SHA512": "sha512",
+}
+
+// oauth1SigningScript signs the request as OAuth1 describes right before
+// httpYac sends it, from the oauth1_ variables. Its %s are the signature
+// method and the hash. Parameters of form bodies are not signed.
+const oauth1SigningScript = `{{@request
+  // Sign the request with OAuth1 %s
+  const crypto = require('crypto');
+  const value = (name) => { try { return String(eval(name)); } catch { return ''; } };
+  const encode = (s) => encodeURIComponent(s).replace(/[!'()*]/g, (c) => '%%' + c.charCodeAt(0).toString(16).toUpperCase());
+  const oauth = {
+    oauth_consumer_key: value('oauth1_consumerKey'),
+    oauth_nonce: value('oauth1_nonce') || crypto.randomBytes(16).toString('hex'),
+    oauth_signature_method: value('oauth1_signatureMethod'),
+    oauth_timestamp: value('oauth1_timestamp') || String(Math.floor(Date.now() / 1000)),
+    oauth_token: value('oauth1_token'),
+    oauth_callback: value('oauth1_callback'),
+    oauth_verifier: value('oauth1_verifier'),
+    oauth_version: value('oauth1_version') || '1.0',
+  };
+  Object.keys(oauth).forEach((key) => oauth[key] || delete oauth[key]);
+  const url = new URL(request.url);
+  const params = [...url.searchParams, ...Object.entries(oauth)].map(([key, val]) => encode(key) + '=' + encode(val)).sort();
+  const base = [request.method.toUpperCase(), encode(url.origin + url.pathname), encode(params.join('&'))].join('&');
+  const key = encode(value('oauth1_consumerSecret')) + '&' + encode(value('oauth1_tokenSecret'));
+  oauth.oauth_signature = crypto.createHmac('%s', key).update(base).digest('base64');
+  const realm = value('oauth1_realm') ? 'realm="' + value('oauth1_realm') + '", ' : '';
+  request.headers.Authorization = 'OAuth ' + realm + Object.entries(oauth).map(([key, val]) => encode(key) + '="' + encode(val) + '"').join(', ');
+}}`
+
+// applyOAuth1 signs the request with the PLAINTEXT method, whose signature
+// is the two secrets, or with a script computing the HMAC signature. RSA
+// signed requests, and requests carrying the OAuth1 parameters elsewhere
+// than in the Authorization header, keep the credentials as variables.
+func applyOAuth1(attributes AuthAttributes, applied *appliedAuth, opts *Options) {
+	if findHeader(applied.Headers, "Authorization") != nil {
+		return
+	}
+
+	// Postman signs with HMAC-SHA1 unless told otherwise
+	method := strings.ToUpper(authAttribute(attributes, "signatureMethod"))
+	if method == "" {
+		method = "HMAC-SHA1"
+	}
+	hash, signed := oauth1Hashes[method]
+	if (method != "PLAINTEXT" && !signed) || authAttribute(attributes, "addParamsToHeader") == "false" {
+		keepAuthVariables("OAuth1 "+method, "oauth1_", oauth1Variables, attributes, applied, opts)
+		applied.Variables = append(applied.Variables, &httpYacVariable{Name: "oauth1_signatureMethod", Value: method})
+		return
+	}
+	if signed {
+		applied.Variables = append(applied.Variables, authVariables("oauth1_", oauth1Variables, attributes)...)
+		applied.Variables = append(applied.Variables, &httpYacVariable{Name: "oauth1_signatureMethod", Value: method})
+		applied.Scripts = append(applied.Scripts, fmt.Sprintf(oauth1SigningScript, method, hash))
+		return
+	}
+
+	var params []string
+	for _, param := range []struct {
+		name  string
+		value string
+	}{
+		{"realm", authAttribute(attributes, "realm")},
+		{"oauth_consumer_key", authAttribute(attributes, "consumerKey")},
+		{"oauth_token", authAttribute(attributes, "token")},
+		{"oauth_signature_method", "PLAINTEXT"},
+		{"oauth_callback", authAttribute(attributes, "callback")},
+		{"oauth_verifier", authAttribute(attributes, "verifier")},
+		{"oauth_version", authAttribute(attributes, "version")},
+		{"oauth_signature", oauth1Encode(authAttribute(attributes, "consumerSecret")) + "&" + oauth1Encode(authAttribute(attributes, "tokenSecret"))},
+	} {
+		if param.value == "" {
+			continue
+		}
+		// The realm is a quoted string of its own, only the OAuth parameters
+		// are percent-encoded
+		value := param.value
+		if param.name != "realm" {
+			value = oauth1Encode(value)
+		}
+		params = append(params, fmt.Sprintf(`%s="%s"`, param.name, value))
+	}
+	applied.Headers = withDefaultHeader(applied.Headers, "Authorization", "OAuth "+strings.Join(params, ", "))
+}
+
+// oauth1Encode percent-encodes all but the unreserved characters, as OAuth1
+// encodes parameters, leaving {{variable}} references to httpYac.
+func oauth1Encode(s string) string {
+	return escapePreservingVariables(s, func(s string) string {
+		sb := strings.Builder{}
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+				sb.WriteByte(c)
+			} else {
+				sb.WriteString(fmt.Sprintf("%%%02X", c))
+			}
+		}
+		return sb.String()
+	})
+}
+
+// applyAWSv4 lets httpYac sign the request with AWS Signature Version 4
+// through "Authorization: AWS <access key> <secret key> [token:] [region:]
+// [service:]".
+func applyAWSv4(attributes AuthAttributes, applied *appliedAuth) {
+	accessKey := authAttribute(attributes, "accessKey")
+	secretKey := authAttribute(attributes, "secretKey")
+	if accessKey == "" && secretKey == "" {
+		return
+	}
+
+	value := fmt.Sprintf("AWS %s %s", accessKey, secretKey)
+	for _, option := range []struct {
+		attribute string
+		name      string
+	}{
+		{"sessionToken", "token"},
+		{"region", "region"},
+		{"service", "service"},
+	} {
+		if optionValue := authAttribute(attributes, option.attribute); optionValue != "" {
+			value += fmt.Sprintf(" %s:%s", option.name, optionValue)
+		}
+	}
+	applied.Headers = withDefaultHeader(applied.Headers, "Authorization", value)
+}
+
+// structuralAuthAttributes are the attributes, by auth type, that shape the
+// auth rather than hold credentials and are kept as they are in shared auth.
+var structuralAuthAttributes = map[string]bool{
+	"apikey.key":                   true,
+	"apikey.in":                    true,
+	"oauth2.grant_type":            true,
+	"oauth2.addTokenTo":            true,
+	"oauth2.client_authentication": true,
+	"oauth1.signatureMethod":       true,
+	"oauth1.addParamsToHeader":     true,
+	"oauth1.version":               true,
+}
+
+// attributes returns the attributes of the auth's type.
+func (a *Auth) attributes() *AuthAttributes {
+	switch a.Type {
+	case "bearer":
+		return &a.Bearer
+	case "basic":
+		return &a.Basic
+	case "apikey":
+		return &a.APIKey
+	case "oauth2":
+		return &a.OAuth2
+	case "digest":
+		return &a.Digest
+	case "ntlm":
+		return &a.NTLM
+	case "awsv4":
+		return &a.AWSv4
+	case "oauth1":
+		return &a.OAuth1
+	case "hawk":
+		return &a.Hawk
+	case "edgegrid":
+		return &a.EdgeGrid
+	}
+	return nil
+}
+
+// sharedAuthVariable names the variable SharedAuthVariables keeps an
+// attribute of the collection auth in.
+func sharedAuthVariable(attribute string) string {
+	return "auth_" + attribute
+}
+
+// SharedAuthVariables returns the credentials of the collection auth as
+// variables, to define them once for requests converted with
+// RequestContext.SharedAuth.
+func SharedAuthVariables(auth *Auth) []*EnvironmentItem {
+	if auth == nil || auth.attributes() == nil {
+		return nil
+	}
+	var variables []*EnvironmentItem
+	for _, attribute := range *auth.attributes() {
+		value, ok := attribute.Value.(string)
+		if !ok || value == "" || structuralAuthAttributes[auth.Type+"."+attribute.Key] {
+			continue
+		}
+		variables = append(variables, &EnvironmentItem{Key: sharedAuthVariable(attribute.Key), Value: VariableValue(value), Enabled: true})
+	}
+	return variables
+}
+
+// sharedAuth returns a copy of auth referencing the variables
+// SharedAuthVariables defines instead of holding the credentials.
+func sharedAuth(auth *Auth) *Auth {
+	if auth == nil || auth.attributes() == nil {
+		return auth
+	}
+	shared := *auth
+	attributes := shared.attributes()
+	references := make(AuthAttributes, 0, len(*attributes))
+	for _, attribute := range *attributes {
+		reference := *attribute
+		if value, ok := attribute.Value.(string); ok && value != "" && !structuralAuthAttributes[auth.Type+"."+attribute.Key] {
+			reference.Value = "{{" + sharedAuthVariable(attribute.Key) + "}}"
+		}
+		references = append(references, &reference)
+	}
+	*attributes = references
+	return &shared
+}
+
+// ResolveAuth returns the auth in effect for a request or folder declaring
+// the given auth block. Without a block of its own, or with an explicit
+// "inherit", the auth of the nearest ancestor applies; "noauth" stops the
+// inheritance.
+func ResolveAuth(auth, inherited *Auth) *Auth {
+	if auth == nil || auth.Type == "inherit" {
+		return inherited
+	}
+	return auth
+}
+
+// authAttribute returns the value of the attribute with the given key. Values
+// are usually strings, anything else is formatted as is.
+func authAttribute(attributes []*AuthAttribute, key string) string {
+	for _, attribute := range attributes {
+		if attribute.Key != key || attribute.Value == nil {
+			continue
+		}
+		if value, ok := attribute.Value.(string); ok {
+			return value
+		}
+		return fmt.Sprint(attribute.Value)
+	}
+	return ""
+}
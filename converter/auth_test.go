@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyOAuth1Plaintext(t *testing.T) {
+	auth := &Auth{Type: "oauth1", OAuth1: AuthAttributes{
+		{Key: "signatureMethod", Value: "PLAINTEXT"},
+		{Key: "realm", Value: "Photos"},
+		{Key: "consumerKey", Value: "key one"},
+		{Key: "consumerSecret", Value: "s&cret"},
+		{Key: "token", Value: "{{token}}"},
+		{Key: "tokenSecret", Value: "a=b"},
+		{Key: "callback", Value: "https://client.example.com/cb?x=1"},
+	}}
+	applied := applyAuth(auth, nil, nil)
+
+	// The secrets are encoded before they are joined, then the signature is
+	// encoded along with the other parameters
+	want := `OAuth realm="Photos", oauth_consumer_key="key%20one", oauth_token="{{token}}", oauth_signature_method="PLAINTEXT", ` +
+		`oauth_callback="https%3A%2F%2Fclient.example.com%2Fcb%3Fx%3D1", oauth_signature="s%2526cret%26a%253Db"`
+	header := findHeader(applied.Headers, "Authorization")
+	if header == nil || header.Value != want {
+		t.Errorf("Authorization = %+v, want %q", header, want)
+	}
+}
+
+func TestApplyOAuth1HMAC(t *testing.T) {
+	auth := &Auth{Type: "oauth1", OAuth1: AuthAttributes{
+		{Key: "signatureMethod", Value: "HMAC-SHA256"},
+		{Key: "consumerKey", Value: "key"},
+		{Key: "consumerSecret", Value: "secret"},
+	}}
+	applied := applyAuth(auth, nil, nil)
+	if len(applied.Headers) != 0 {
+		t.Errorf("Headers = %+v, want the script to set Authorization", applied.Headers)
+	}
+	if len(applied.Scripts) != 1 {
+		t.Fatalf("Scripts = %q, want one signing script", applied.Scripts)
+	}
+
+	script := applied.Scripts[0]
+	for _, line := range []string{
+		"{{@request\n",
+		"  // Sign the request with OAuth1 HMAC-SHA256\n",
+		"  const encode = (s) => encodeURIComponent(s).replace(/[!'()*]/g, (c) => '%' + c.charCodeAt(0).toString(16).toUpperCase());\n",
+		"  const key = encode(value('oauth1_consumerSecret')) + '&' + encode(value('oauth1_tokenSecret'));\n",
+		"  oauth.oauth_signature = crypto.createHmac('sha256', key).update(base).digest('base64');\n",
+		"  request.headers.Authorization = 'OAuth ' + realm + Object.entries(oauth).map(([key, val]) => encode(key) + '=\"' + encode(val) + '\"').join(', ');\n",
+		"}}",
+	} {
+		if !strings.Contains(script, line) {
+			t.Errorf("script = %s\nwant it to contain %q", script, line)
+		}
+	}
+
+	variables := map[string]string{}
+	for _, variable := range applied.Variables {
+		variables[variable.Name] = variable.Value
+	}
+	if variables["oauth1_signatureMethod"] != "HMAC-SHA256" || variables["oauth1_consumerSecret"] != "secret" {
+		t.Errorf("Variables = %v, want the signature method and credentials", variables)
+	}
+}
@@ -0,0 +1,404 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// multipartBoundary is used for formdata bodies unless the request already
+// declares its own boundary in the Content-Type header.
+const multipartBoundary = "PostmanToHttpYacBoundary"
+
+// renderBody returns the httpYac representation of a Postman body together
+// with the headers, completed with the Content-Type implied by the body mode.
+// Relative file paths are resolved against opts.FilesDir.
+func renderBody(body *Body, headers []*Header, opts *Options) (string, []*Header) {
+	switch body.Mode {
+	case "urlencoded":
+		rendered := renderURLEncodedBody(body.URLEncoded)
+		if rendered == "" {
+			return "", headers
+		}
+		return rendered, withDefaultHeader(headers, "Content-Type", "application/x-www-form-urlencoded")
+	case "formdata":
+		boundary, completed := multipartContentType(headers)
+		rendered := renderFormDataBody(body.FormData, boundary, opts.FilesDir)
+		if rendered == "" {
+			return "", headers
+		}
+		return rendered, completed
+	case "graphql":
+		rendered := renderGraphQLBody(body.GraphQL)
+		if rendered == "" {
+			return "", headers
+		}
+		return rendered, withDefaultHeader(headers, "Content-Type", "application/json")
+	case "file":
+		return renderFileBody(body.File, opts), headers
+	default:
+		if body.Raw == "" {
+			return "", headers
+		}
+		contentType := rawContentType(body)
+		if contentType == "" && rawLanguage(body) == "" && looksLikeXML(body.Raw) {
+			contentType = "application/xml"
+		}
+		if contentType != "" {
+			headers = withDefaultHeader(headers, "Content-Type", contentType)
+		}
+		switch {
+		case isJSONContentType(headers):
+			return indentJSON(body.Raw), headers
+		case isXMLContentType(headers):
+			return indentXML(body.Raw), headers
+		}
+		return body.Raw, headers
+	}
+}
+
+// binaryMediaTypePrefixes are the media types, or their type part, whose raw
+// bodies hold binary data, written as base64 since Postman keeps raw bodies
+// as text.
+var binaryMediaTypePrefixes = []string{"application/octet-stream", "application/pdf", "application/zip", "application/gzip", "image/", "audio/", "video/"}
+
+// binaryBody returns the data of a raw body that is binary rather than text:
+// one holding control characters, or base64 sent with a binary Content-Type.
+func binaryBody(body *Body, headers []*Header) ([]byte, bool) {
+	if (body.Mode != "raw" && body.Mode != "") || body.Raw == "" {
+		return nil, false
+	}
+	if strings.ContainsFunc(body.Raw, func(r rune) bool {
+		return r == utf8.RuneError || (unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r')
+	}) {
+		return []byte(body.Raw), true
+	}
+
+	header := findHeader(headers, "Content-Type")
+	if header == nil {
+		return nil, false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Value)
+	if err != nil {
+		return nil, false
+	}
+	for _, prefix := range binaryMediaTypePrefixes {
+		if strings.HasPrefix(mediaType, prefix) {
+			data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body.Raw), ""))
+			return data, err == nil && len(data) > 0
+		}
+	}
+	return nil, false
+}
+
+// isJSONContentType reports whether the Content-Type header declares JSON,
+// including types like application/vnd.api+json.
+func isJSONContentType(headers []*Header) bool {
+	header := findHeader(headers, "Content-Type")
+	if header == nil {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Value)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// indentJSON re-indents JSON with two spaces, leaving anything that does not
+// parse as JSON, such as bodies with unquoted {{variables}}, untouched.
+func indentJSON(raw string) string {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(raw), "", "  "); err != nil {
+		return raw
+	}
+	return indented.String()
+}
+
+// isXMLContentType reports whether the Content-Type header declares XML,
+// including types like application/soap+xml.
+func isXMLContentType(headers []*Header) bool {
+	header := findHeader(headers, "Content-Type")
+	if header == nil {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Value)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// looksLikeXML reports whether a raw body of unknown language is well-formed
+// XML.
+func looksLikeXML(raw string) bool {
+	if !strings.HasPrefix(strings.TrimSpace(raw), "<") {
+		return false
+	}
+	_, err := formatXML(raw)
+	return err == nil
+}
+
+// indentXML puts every element on its own line indented with two spaces,
+// leaving anything that is not well-formed XML untouched.
+func indentXML(raw string) string {
+	formatted, err := formatXML(raw)
+	if err != nil {
+		return raw
+	}
+	return formatted
+}
+
+// formatXML indents XML while copying every element, text and comment as
+// written, so that entities, namespace prefixes and {{variables}} survive.
+// Elements holding only text stay on one line.
+func formatXML(raw string) (string, error) {
+	const (
+		afterStart = iota
+		afterText
+		afterOther
+	)
+
+	sb := strings.Builder{}
+	writeLine := func(depth int, text string) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(text)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(raw))
+	var open []xml.Name
+	state := afterOther
+	var offset int64
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		text := raw[offset:decoder.InputOffset()]
+		offset = decoder.InputOffset()
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			writeLine(len(open), text)
+			open = append(open, token.Name)
+			state = afterStart
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != token.Name {
+				return "", fmt.Errorf("unexpected end element </%s>", token.Name.Local)
+			}
+			open = open[:len(open)-1]
+			// Self-closing elements were written with their start element
+			switch {
+			case text == "":
+			case state == afterStart || state == afterText:
+				sb.WriteString(text)
+			default:
+				writeLine(len(open), text)
+			}
+			state = afterOther
+		case xml.CharData:
+			trimmed := strings.TrimSpace(text)
+			if trimmed == "" {
+				continue
+			}
+			if state == afterStart {
+				sb.WriteString(trimmed)
+				state = afterText
+			} else {
+				writeLine(len(open), trimmed)
+				state = afterOther
+			}
+		default:
+			writeLine(len(open), strings.TrimSpace(text))
+			state = afterOther
+		}
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("unclosed element <%s>", open[len(open)-1].Local)
+	}
+	return sb.String(), nil
+}
+
+// rawLanguageContentTypes maps the languages Postman lets raw bodies be
+// edited in to the Content-Type Postman sends them with.
+var rawLanguageContentTypes = map[string]string{
+	"json":       "application/json",
+	"xml":        "application/xml",
+	"html":       "text/html",
+	"javascript": "application/javascript",
+	"text":       "text/plain",
+}
+
+// rawLanguage returns the language a raw body was edited in, if Postman
+// recorded one.
+func rawLanguage(body *Body) string {
+	if body.Options == nil || body.Options.Raw == nil {
+		return ""
+	}
+	return body.Options.Raw.Language
+}
+
+// rawContentType returns the Content-Type for the language of a raw body,
+// text/plain for languages not known, none without a language.
+func rawContentType(body *Body) string {
+	language := rawLanguage(body)
+	if language == "" {
+		return ""
+	}
+	if contentType, ok := rawLanguageContentTypes[strings.ToLower(language)]; ok {
+		return contentType
+	}
+	return "text/plain"
+}
+
+// renderURLEncodedBody writes one key=value pair per line, continuing each
+// following line with '&' as httpYac allows for form bodies.
+func renderURLEncodedBody(params []*URLEncodedParam) string {
+	var lines []string
+	for _, param := range params {
+		if param.Disabled {
+			continue
+		}
+
+		line := escapePreservingVariables(param.Key, url.QueryEscape) + "=" + escapePreservingVariables(param.Value, url.QueryEscape)
+		if len(lines) > 0 {
+			line = "&" + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderGraphQLBody writes the query verbatim followed by the variables JSON,
+// separated by a blank line, which httpYac sends as a GraphQL request.
+func renderGraphQLBody(graphQL *GraphQL) string {
+	if graphQL == nil || strings.TrimSpace(graphQL.Query) == "" {
+		return ""
+	}
+
+	rendered := graphQL.Query
+	if variables := strings.TrimSpace(graphQL.Variables); variables != "" {
+		rendered = strings.TrimRight(rendered, "\n") + "\n\n" + variables
+	}
+	return rendered
+}
+
+// multipartContentType returns the boundary to separate form parts with. A
+// boundary declared by the request is kept, a Content-Type without one gets
+// the default boundary appended, since httpYac sends the header as written.
+func multipartContentType(headers []*Header) (string, []*Header) {
+	header := findHeader(headers, "Content-Type")
+	if header == nil {
+		return multipartBoundary, withDefaultHeader(headers, "Content-Type", "multipart/form-data; boundary="+multipartBoundary)
+	}
+
+	if _, params, err := mime.ParseMediaType(header.Value); err == nil && params["boundary"] != "" {
+		return params["boundary"], headers
+	}
+
+	completed := make([]*Header, 0, len(headers))
+	for _, h := range headers {
+		if h == header {
+			h = &Header{Key: h.Key, Value: strings.TrimRight(h.Value, "; ") + "; boundary=" + multipartBoundary}
+		}
+		completed = append(completed, h)
+	}
+	return multipartBoundary, completed
+}
+
+func renderFormDataBody(params []*FormDataParam, boundary, filesDir string) string {
+	sb := strings.Builder{}
+	for _, param := range params {
+		if param.Disabled {
+			continue
+		}
+
+		if param.Type == "file" {
+			for _, src := range param.Sources() {
+				sb.WriteString(fmt.Sprintf("--%s\n", boundary))
+				sb.WriteString(fmt.Sprintf("Content-Disposition: form-data; name=\"%s\"; filename=\"%s\"\n", escapeQuotedParam(param.Key), escapeQuotedParam(path.Base(fileReference(src, filesDir)))))
+				if param.ContentType != "" {
+					sb.WriteString(fmt.Sprintf("Content-Type: %s\n", param.ContentType))
+				}
+				sb.WriteString(fmt.Sprintf("\n< %s\n", fileReference(src, filesDir)))
+			}
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("--%s\n", boundary))
+		sb.WriteString(fmt.Sprintf("Content-Disposition: form-data; name=\"%s\"\n", escapeQuotedParam(param.Key)))
+		if param.ContentType != "" {
+			sb.WriteString(fmt.Sprintf("Content-Type: %s\n", param.ContentType))
+		}
+		sb.WriteString(fmt.Sprintf("\n%s\n", param.Value))
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+	sb.WriteString(fmt.Sprintf("--%s--", boundary))
+	return sb.String()
+}
+
+// escapeQuotedParam percent-encodes the characters that would end a quoted
+// Content-Disposition parameter, the same way browsers encode field names.
+func escapeQuotedParam(s string) string {
+	return strings.NewReplacer(`"`, "%22", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// renderFileBody references the file a binary body is sent from, falling
+// back to the content Postman may have stored instead.
+func renderFileBody(file *BodyFile, opts *Options) string {
+	if file == nil || file.Src == "" {
+		if file != nil && file.Content != "" {
+			return file.Content
+		}
+		opts.warnf("Warning: file body without a source file, leaving the body empty")
+		return ""
+	}
+	if isAbsFilePath(file.Src) {
+		opts.warnf("Warning: file body source %s is absolute and will only resolve on the machine it was exported from", file.Src)
+	}
+	return "< " + fileReference(file.Src, opts.FilesDir)
+}
+
+// fileReference turns a Postman file path into the relative form httpYac
+// resolves against the location of the .http file, below filesDir if set.
+func fileReference(src, filesDir string) string {
+	src = strings.ReplaceAll(src, "\\", "/")
+	if isAbsFilePath(src) {
+		return src
+	}
+	if filesDir != "" {
+		src = path.Join(strings.ReplaceAll(filesDir, "\\", "/"), src)
+		if isAbsFilePath(src) {
+			return src
+		}
+	}
+	if strings.HasPrefix(src, "./") || strings.HasPrefix(src, "../") {
+		return src
+	}
+	return "./" + src
+}
+
+// isAbsFilePath reports whether src is an absolute path, on Unix or Windows.
+func isAbsFilePath(src string) bool {
+	src = strings.ReplaceAll(src, "\\", "/")
+	isWindowsAbs := len(src) > 1 && src[1] == ':'
+	return path.IsAbs(src) || isWindowsAbs
+}
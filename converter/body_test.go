@@ -0,0 +1,73 @@
+package converter
+
+import "testing"
+
+func TestFormatXML(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "nested elements",
+			raw:  `<a><b>1</b><c/></a>`,
+			want: "<a>\n  <b>1</b>\n  <c/>\n</a>",
+		},
+		{
+			name: "declaration, comment and CDATA",
+			raw:  `<?xml version="1.0"?><a><!-- note --><b><![CDATA[x < y]]></b></a>`,
+			want: "<?xml version=\"1.0\"?>\n<a>\n  <!-- note -->\n  <b><![CDATA[x < y]]></b>\n</a>",
+		},
+		{
+			name: "CDATA holding markup",
+			raw:  `<a><![CDATA[<b>1</b>]]><c>2</c></a>`,
+			want: "<a><![CDATA[<b>1</b>]]>\n  <c>2</c>\n</a>",
+		},
+		{
+			name: "comment before mixed content",
+			raw:  `<a>  <!--c--><b>x</b>text</a>`,
+			want: "<a>\n  <!--c-->\n  <b>x</b>\n  text\n</a>",
+		},
+		{
+			name: "variables and entities",
+			raw:  `<a><b>{{id}}</b><c attr="{{v}}">&amp;</c></a>`,
+			want: "<a>\n  <b>{{id}}</b>\n  <c attr=\"{{v}}\">&amp;</c>\n</a>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatXML(tt.raw)
+			if err != nil {
+				t.Fatalf("formatXML() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatXML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatXMLMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "mismatched end element", raw: `<a><b></a>`},
+		{name: "unclosed element", raw: `<a><b>1</b>`},
+		{name: "end element only", raw: `</a>`},
+		{name: "unquoted attribute", raw: `<a attr=x/>`},
+		{name: "unterminated comment", raw: `<a><!-- note</a>`},
+		{name: "unterminated CDATA", raw: `<a><![CDATA[x</a>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := formatXML(tt.raw); err == nil {
+				t.Errorf("formatXML(%q) error = nil, want an error", tt.raw)
+			}
+			// The body is sent as written rather than half formatted
+			if got := indentXML(tt.raw); got != tt.raw {
+				t.Errorf("indentXML() = %q, want %q", got, tt.raw)
+			}
+		})
+	}
+}
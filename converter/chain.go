@@ -0,0 +1,181 @@
+package converter
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableReferencePattern matches the {{name}} references of a request,
+// leaving out dynamic variables which no request sets.
+var variableReferencePattern = regexp.MustCompile(`\{\{\s*([^{}\s$][^{}]*?)\s*\}\}`)
+
+// RequestContext carries what a request takes from the folders and requests
+// around it.
+type RequestContext struct {
+	Auth *Auth
+	// Whether the request is in a disabled folder
+	Disabled bool
+	// Whether Auth is the collection auth, whose credentials are defined
+	// once as the variables SharedAuthVariables returns
+	SharedAuth bool
+	// Headers httpYac sends with every request, left out of the request
+	// where it would send the same value
+	DefaultHeaders []*Header
+	// Name the request is written under, RequestIdentifier of the item name
+	// when empty
+	Name string
+	// Names of the requests to run before this one
+	Refs []string
+	// Files defining the referenced requests and the common headers, relative
+	// to this request's file
+	Imports []string
+	// Headers of the folders around the request, the request's own headers
+	// replace those with the same key
+	Headers []*Header
+	// File next to the request's file to move a binary body to, the body is
+	// kept inline without one
+	BodyFileName string
+	// Binary body ConvertRequest moved out of the request, for the caller to
+	// write to BodyFileName
+	BodyData []byte
+	// Headers whose values are defined once for the folder as the variables
+	// CommonHeaderVariables returns
+	CommonHeaders []*Header
+}
+
+// capturedVariables returns the variables the test script of item sets.
+func capturedVariables(item *Item) []string {
+	var variables []string
+	for _, capture := range translateTestScript(eventScript(item.Events, "test")).Captures {
+		variables = append(variables, capture.Variable)
+	}
+	return variables
+}
+
+// referencedVariables returns the variables used in the URL, headers, body
+// and auth of item.
+func referencedVariables(item *Item, auth *Auth) []string {
+	request := item.Request
+	sources := []string{string(request.URL), string(request.Body)}
+	for _, header := range request.Header {
+		if !header.Disabled {
+			sources = append(sources, header.Key, header.Value)
+		}
+	}
+	if auth != nil {
+		if data, err := json.Marshal(auth); err == nil {
+			sources = append(sources, string(data))
+		}
+	}
+
+	var variables []string
+	seen := map[string]bool{}
+	for _, match := range variableReferencePattern.FindAllStringSubmatch(strings.Join(sources, "\n"), -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// ChainRequests orders the requests among items so that each one comes
+// after the requests setting the variables it uses, and returns the
+// requests each one depends on. Folders follow the requests in their
+// original order. Requests depending on each other keep their original
+// order.
+func ChainRequests(items []*Item, inheritedAuth *Auth) ([]*Item, map[*Item][]*Item) {
+	var requests, folders []*Item
+	for _, item := range items {
+		if item.Request != nil {
+			requests = append(requests, item)
+		} else {
+			folders = append(folders, item)
+		}
+	}
+
+	// The first request setting a variable is the one the others wait for
+	setters := map[string]*Item{}
+	for _, item := range requests {
+		for _, variable := range capturedVariables(item) {
+			if setters[variable] == nil {
+				setters[variable] = item
+			}
+		}
+	}
+
+	dependencies := map[*Item][]*Item{}
+	for _, item := range requests {
+		for _, variable := range referencedVariables(item, ResolveAuth(item.Request.Auth, inheritedAuth)) {
+			setter := setters[variable]
+			if setter == nil || setter == item || containsItem(dependencies[item], setter) {
+				continue
+			}
+			dependencies[item] = append(dependencies[item], setter)
+		}
+	}
+
+	// Repeatedly take the first request whose dependencies are all placed,
+	// falling back to the first remaining one when they wait on each other
+	index := map[*Item]int{}
+	for i, item := range requests {
+		index[item] = i
+	}
+	placed := map[*Item]bool{}
+	var ordered []*Item
+	for len(ordered) < len(requests) {
+		next := -1
+		for i, item := range requests {
+			if placed[item] {
+				continue
+			}
+			if next == -1 {
+				next = i
+			}
+			if allPlaced(dependencies[item], placed) {
+				next = i
+				break
+			}
+		}
+		placed[requests[next]] = true
+		ordered = append(ordered, requests[next])
+	}
+
+	// Only reference requests placed before, a cycle can't be run in order
+	for item, setters := range dependencies {
+		var before []*Item
+		for _, setter := range setters {
+			if positionOf(ordered, setter) < positionOf(ordered, item) {
+				before = append(before, setter)
+			}
+		}
+		sort.SliceStable(before, func(i, j int) bool { return index[before[i]] < index[before[j]] })
+		dependencies[item] = before
+	}
+
+	return append(ordered, folders...), dependencies
+}
+
+func containsItem(items []*Item, item *Item) bool {
+	return positionOf(items, item) != -1
+}
+
+func positionOf(items []*Item, item *Item) int {
+	for i, candidate := range items {
+		if candidate == item {
+			return i
+		}
+	}
+	return -1
+}
+
+func allPlaced(items []*Item, placed map[*Item]bool) bool {
+	for _, item := range items {
+		if !placed[item] {
+			return false
+		}
+	}
+	return true
+}
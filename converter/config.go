@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Config -
+type Config struct {
+	// Rewrites applied to the URL, headers and body of every request
+	Substitutions []*Substitution `json:"substitutions"`
+}
+
+// Substitution -
+type Substitution struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseConfig parses a JSON config and compiles its substitutions.
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	for i, substitution := range config.Substitutions {
+		var err error
+		substitution.pattern, err = regexp.Compile(substitution.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("substitution %d: %w", i+1, err)
+		}
+	}
+	return config, nil
+}
+
+// substitute applies the substitutions to s in the order they are listed.
+// Replacements can refer to the groups of their pattern as in
+// regexp.Regexp.Expand, e.g. $1.
+func (c *Config) substitute(s string) string {
+	if c == nil {
+		return s
+	}
+	for _, substitution := range c.Substitutions {
+		s = substitution.pattern.ReplaceAllString(s, substitution.Replacement)
+	}
+	return s
+}
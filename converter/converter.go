@@ -0,0 +1,193 @@
+// Package converter turns Postman collections and environments into httpYac
+// .http requests and .env files.
+package converter
+
+import "encoding/json"
+
+// Options -
+type Options struct {
+	// Keep header descriptions as comments above the headers
+	HeaderDescriptions bool
+	// Order requests after the ones setting the variables they use
+	Chain bool
+	// Directory relative paths of uploaded files are resolved against
+	FilesDir string
+	// Leave the values of secret environment variables out
+	MaskSecrets bool
+	// Define environment variables before the values referencing them
+	SortVariables bool
+	// Convert disabled requests and folders commented out instead of skipping them
+	IncludeDisabled bool
+	// Write header keys in their canonical form, e.g. "content-type" ->
+	// "Content-Type"
+	NormalizeHeaders bool
+	Config           *Config
+	// Write all requests of a folder into a single file, separated by ###
+	SingleFile bool
+	// Write the requests of folders into the collection directory, prefixing
+	// their file names with the folder path
+	Flatten bool
+	// Separator between the folder names and the request name of flattened
+	// files, "__" when empty
+	FlattenSeparator string
+	// Number of folder levels to create directories for, 0 for no limit
+	MaxDepth int
+	// File extension of the converted requests, ".http" when empty
+	Extension string
+	// Define the headers all requests of a folder send once
+	CommonHeaders bool
+	// Define the credentials of the collection auth once in the .env file
+	// and its headers in the default headers of .httpyac.json
+	SharedAuth bool
+	// Name the request files after a template of {name}, {method} and {host}
+	FileNameTemplate string
+	// Warnf reports what can't be carried over to httpYac, such as auth
+	// httpYac does not support. Warnings are discarded when nil
+	Warnf func(format string, args ...interface{})
+}
+
+// orDefault returns o, or the zero Options when o is nil.
+func (o *Options) orDefault() *Options {
+	if o == nil {
+		return &Options{}
+	}
+	return o
+}
+
+// warnf reports a warning through Warnf, when set.
+func (o *Options) warnf(format string, args ...interface{}) {
+	if o != nil && o.Warnf != nil {
+		o.Warnf(format, args...)
+	}
+}
+
+// ConvertedRequest -
+type ConvertedRequest struct {
+	// Folders containing the request, outermost first
+	Folders []*Item
+	Item    *Item
+	// httpYac representation of the request, empty if Err is set
+	Content string
+	// Why the request could not be converted, of type *ConvertError
+	Err error
+}
+
+// ConvertCollection converts the requests of a Postman collection, depth
+// first. Requests that fail to convert are returned with their error, the
+// *ParseError returned is for collections that can't be read at all.
+func ConvertCollection(data []byte, opts *Options) ([]*ConvertedRequest, error) {
+	opts = opts.orDefault()
+	collection, err := ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	return convertItems(collection.Items, nil, collection.Auth, nil, false, map[string]bool{}, opts), nil
+}
+
+// convertItems converts the requests of items and their folders, naming
+// them apart from the names in used, as they are joined into one document.
+func convertItems(items []*Item, folders []*Item, inheritedAuth *Auth, inheritedHeaders []*Header, disabled bool, used map[string]bool, opts *Options) []*ConvertedRequest {
+	var converted []*ConvertedRequest
+	items = EnabledItems(items, opts)
+	names := requestIdentifiers(items, used)
+	var dependencies map[*Item][]*Item
+	if opts.Chain {
+		items, dependencies = ChainRequests(items, inheritedAuth)
+	}
+	for _, item := range items {
+		if item.Request != nil {
+			ctx := &RequestContext{Auth: ResolveAuth(item.Request.Auth, inheritedAuth), Disabled: disabled, Headers: inheritedHeaders, Name: names[item]}
+			for _, dependency := range dependencies[item] {
+				ctx.Refs = append(ctx.Refs, names[dependency])
+			}
+			content, err := ConvertRequest(item, ctx, opts)
+			if err != nil {
+				err = &ConvertError{Request: item.Name, Err: err}
+			}
+			converted = append(converted, &ConvertedRequest{Folders: folders, Item: item, Content: content, Err: err})
+		}
+
+		if len(item.Items) > 0 {
+			nestedFolders := append(append([]*Item(nil), folders...), item)
+			converted = append(converted, convertItems(item.Items, nestedFolders, ResolveAuth(item.Auth, inheritedAuth), InheritHeaders(inheritedHeaders, item), disabled || item.Disabled, used, opts)...)
+		}
+	}
+	return converted
+}
+
+// EnabledItems returns items without the disabled ones, which Postman's
+// runner skips, unless they are to be included.
+func EnabledItems(items []*Item, opts *Options) []*Item {
+	if opts.orDefault().IncludeDisabled {
+		return items
+	}
+	var enabled []*Item
+	for _, item := range items {
+		if !item.Disabled {
+			enabled = append(enabled, item)
+		}
+	}
+	return enabled
+}
+
+// HasRequests reports whether items hold a request to convert, directly or
+// in their folders.
+func HasRequests(items []*Item, opts *Options) bool {
+	for _, item := range EnabledItems(items, opts) {
+		if item.Request != nil || HasRequests(item.Items, opts) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertEnvironment converts a Postman environment into a .env file.
+func ConvertEnvironment(data []byte, opts *Options) (string, error) {
+	opts = opts.orDefault()
+	environment, err := ParseEnvironment(data, opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.SortVariables {
+		environment.Values = SortVariables(environment.Values)
+	}
+	return environment.Dotenv(opts.MaskSecrets), nil
+}
+
+// ParseEnvironment parses a Postman environment. Errors are of type
+// *ParseError. Keys defined more than once are reported through
+// opts.Warnf, opts may be nil.
+func ParseEnvironment(data []byte, opts *Options) (*PostmanEnvironment, error) {
+	environment := &PostmanEnvironment{}
+	if err := json.Unmarshal(data, environment); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	environment.Values = lastEnabledValues(environment.Name, environment.Values, opts)
+	return environment, nil
+}
+
+// lastEnabledValues drops the enabled values whose key is enabled again
+// later in the environment, so that the last one wins as in Postman and the
+// converted environment defines every key once.
+func lastEnabledValues(name string, values []*EnvironmentItem, opts *Options) []*EnvironmentItem {
+	last := map[string]int{}
+	for i, value := range values {
+		if value.IsEnabled() {
+			last[value.Key] = i
+		}
+	}
+
+	kept := make([]*EnvironmentItem, 0, len(values))
+	warned := map[string]bool{}
+	for i, value := range values {
+		if value.IsEnabled() && last[value.Key] != i {
+			if !warned[value.Key] {
+				opts.warnf("Warning: environment %s defines %s more than once, keeping the last value", name, value.Key)
+				warned[value.Key] = true
+			}
+			continue
+		}
+		kept = append(kept, value)
+	}
+	return kept
+}
@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const nilOptionsCollection = `{
+	"info": {"name": "nil", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"item": [{"name": "Get", "request": {"method": "GET", "url": "{{baseUrl}}/users"}}]
+}`
+
+func TestConvertWithNilOptions(t *testing.T) {
+	converted, err := ConvertCollection([]byte(nilOptionsCollection), nil)
+	if err != nil {
+		t.Fatalf("ConvertCollection() error = %v", err)
+	}
+	if len(converted) != 1 || converted[0].Err != nil {
+		t.Fatalf("ConvertCollection() = %+v, want one converted request", converted)
+	}
+	if !strings.Contains(converted[0].Content, "GET {{baseUrl}}/users\n") {
+		t.Errorf("ConvertCollection() content = %q, want the request line", converted[0].Content)
+	}
+
+	item := &Item{Name: "Get", Request: &Request{Method: "GET", URL: []byte(`"https://api.example.com"`)}}
+	if _, err := ConvertRequest(item, nil, nil); err != nil {
+		t.Errorf("ConvertRequest() error = %v", err)
+	}
+
+	dotenv, err := ConvertEnvironment([]byte(`{"name": "dev", "values": [{"key": "baseUrl", "value": "https://api.example.com", "enabled": true}]}`), nil)
+	if err != nil {
+		t.Fatalf("ConvertEnvironment() error = %v", err)
+	}
+	if dotenv != "baseUrl=https://api.example.com\n" {
+		t.Errorf("ConvertEnvironment() = %q", dotenv)
+	}
+
+	collection, err := ParseCollection([]byte(nilOptionsCollection))
+	if err != nil {
+		t.Fatalf("ParseCollection() error = %v", err)
+	}
+	files, errs := ConvertCollectionFiles(collection, "nil", nil)
+	if len(errs) > 0 {
+		t.Fatalf("ConvertCollectionFiles() errors = %v", errs)
+	}
+	var paths []string
+	for _, file := range files.Files {
+		paths = append(paths, file.Path)
+	}
+	if got := strings.Join(paths, ","); got != "_collection.http,Get.http" {
+		t.Errorf("ConvertCollectionFiles() paths = %s", got)
+	}
+}
+
+func TestWarnf(t *testing.T) {
+	var warnings []string
+	opts := &Options{Warnf: func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}}
+	data := `{"name": "dev", "values": [{"key": "a", "value": "1", "enabled": true}, {"key": "a", "value": "2", "enabled": true}]}`
+	if _, err := ConvertEnvironment([]byte(data), opts); err != nil {
+		t.Fatalf("ConvertEnvironment() error = %v", err)
+	}
+	want := "Warning: environment dev defines a more than once, keeping the last value"
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+const duplicateNamesCollection = `{
+	"info": {"name": "dup", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"item": [
+		{"name": "Get", "request": {"method": "GET", "url": "{{baseUrl}}/a"}, "event": [{"listen": "test", "script": {"exec": ["pm.environment.set(\"token\", pm.response.json().token);"]}}]},
+		{"name": "get", "request": {"method": "GET", "url": "{{baseUrl}}/b", "header": [{"key": "Authorization", "value": "Bearer {{token}}"}]}},
+		{"name": "Folder", "item": [{"name": "Get", "request": {"method": "GET", "url": "{{baseUrl}}/c"}}]}
+	]
+}`
+
+func TestConvertCollectionRequestNames(t *testing.T) {
+	converted, err := ConvertCollection([]byte(duplicateNamesCollection), &Options{Chain: true})
+	if err != nil {
+		t.Fatalf("ConvertCollection() error = %v", err)
+	}
+	want := []string{"# @name get\n", "# @name get_2\n# @ref get\n", "# @name get_3\n"}
+	if len(converted) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(converted), len(want))
+	}
+	for i, request := range converted {
+		if !strings.HasPrefix(request.Content, want[i]) {
+			t.Errorf("request %d = %q, want it to start with %q", i, request.Content, want[i])
+		}
+	}
+}
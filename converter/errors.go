@@ -0,0 +1,41 @@
+package converter
+
+import "fmt"
+
+// ParseError - a collection or environment that could not be read
+type ParseError struct {
+	// File the data was read from, empty when the caller did not say
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("parsing: %v", e.Err)
+	}
+	return fmt.Sprintf("parsing %s: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ConvertError - a request that could not be converted
+type ConvertError struct {
+	// File of the collection holding the request, empty when the caller did
+	// not say
+	Path    string
+	Request string
+	Err     error
+}
+
+func (e *ConvertError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("converting request %s: %v", e.Request, e.Err)
+	}
+	return fmt.Sprintf("converting request %s of %s: %v", e.Request, e.Path, e.Err)
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InheritHeaders returns the headers the requests in folder get, given the
+// ones inherited from the folders around it. The headers of the folder
+// replace the inherited ones with the same key.
+func InheritHeaders(inherited []*Header, folder *Item) []*Header {
+	return mergeHeaders(inherited, folder.Header)
+}
+
+// mergeHeaders returns the headers of base followed by overrides, leaving
+// out those of base whose key an enabled header of overrides has.
+func mergeHeaders(base, overrides []*Header) []*Header {
+	if len(base) == 0 {
+		return overrides
+	}
+	overridden := map[string]bool{}
+	for _, header := range overrides {
+		if !header.Disabled {
+			overridden[strings.ToLower(strings.TrimSpace(header.Key))] = true
+		}
+	}
+
+	merged := make([]*Header, 0, len(base)+len(overrides))
+	for _, header := range base {
+		if !overridden[strings.ToLower(strings.TrimSpace(header.Key))] {
+			merged = append(merged, header)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// CommonHeaders returns the headers every request of items sends with the
+// same key and value, in the order of the first request. Folders holding
+// fewer than two requests have nothing to share.
+func CommonHeaders(items []*Item) []*Header {
+	var requests []*Request
+	for _, item := range items {
+		if item.Request != nil {
+			requests = append(requests, item.Request)
+		}
+	}
+	if len(requests) < 2 {
+		return nil
+	}
+
+	var common []*Header
+	variables := map[string]bool{}
+	for _, header := range requests[0].Header {
+		// Repeated keys would need the same variable for different values
+		if header.Disabled || variables[CommonHeaderVariable(header.Key)] {
+			continue
+		}
+		shared := true
+		for _, request := range requests[1:] {
+			if findCommonHeader(request.Header, header) == nil {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			common = append(common, &Header{Key: strings.TrimSpace(header.Key), Value: header.Value})
+			variables[CommonHeaderVariable(header.Key)] = true
+		}
+	}
+	return common
+}
+
+// findCommonHeader returns the enabled header of headers with exactly the
+// key and value of header.
+func findCommonHeader(headers []*Header, header *Header) *Header {
+	for _, h := range headers {
+		if !h.Disabled && strings.TrimSpace(h.Key) == strings.TrimSpace(header.Key) && h.Value == header.Value {
+			return h
+		}
+	}
+	return nil
+}
+
+// CommonHeaderVariable returns the variable holding the value of a common
+// header, e.g. "X-Api-Version" -> "header_x_api_version".
+func CommonHeaderVariable(key string) string {
+	return "header_" + RequestIdentifier(key)
+}
+
+// CommonHeaderVariables renders the variables holding the values of the
+// common headers, to define once for all the requests of a folder.
+func CommonHeaderVariables(headers []*Header, opts *Options) string {
+	dynamic := &dynamicVariableTranslator{}
+	sb := strings.Builder{}
+	for _, header := range headers {
+		sb.WriteString(fmt.Sprintf("@%s = %s\n", CommonHeaderVariable(header.Key), dynamic.translate(opts.orDefault().Config.substitute(header.Value))))
+	}
+	return sb.String()
+}
+
+// withCommonHeaders returns the headers with the values of the common ones
+// replaced by their variable, leaving the headers of the request untouched.
+func withCommonHeaders(headers, common []*Header) []*Header {
+	if len(common) == 0 {
+		return headers
+	}
+	replaced := make([]*Header, 0, len(headers))
+	for _, header := range headers {
+		if findCommonHeader(common, header) != nil {
+			copied := *header
+			copied.Value = "{{" + CommonHeaderVariable(header.Key) + "}}"
+			header = &copied
+		}
+		replaced = append(replaced, header)
+	}
+	return replaced
+}
@@ -0,0 +1,467 @@
+package converter
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// collectionInfoFileName is the file, without extension, documenting the
+// collection.
+const collectionInfoFileName = "_collection"
+
+// bodyFileExtension is the extension of the files binary request bodies are
+// moved to.
+const bodyFileExtension = ".body"
+
+// commonHeadersFileName is the file, without extension, defining the
+// headers common to the requests of a folder.
+const commonHeadersFileName = "_common"
+
+// httpYacConfigFileName is the httpYac configuration file of a collection,
+// which also makes its directory the root httpYac loads the .env file from.
+const httpYacConfigFileName = ".httpyac.json"
+
+// maxNameLength is the length in bytes file and directory names are
+// truncated to, leaving room for the extension and deduplication suffix.
+const maxNameLength = 100
+
+// maxFolderPathLength is the length in bytes the directories of folders are
+// allowed to reach within the output directory of their collection. Deeper
+// folders are flattened, keeping paths within the Windows limit.
+const maxFolderPathLength = 120
+
+// Defaults of the layout options left empty.
+const (
+	defaultExtension        = ".http"
+	defaultFlattenSeparator = "__"
+)
+
+var underscoreRunPattern = regexp.MustCompile(`_{2,}`)
+
+// OutputFile - a file of a converted collection, kept in memory until the
+// caller writes it
+type OutputFile struct {
+	// Path relative to the output directory of the collection
+	Path    string
+	Content string
+	// Whether the file holds the binary body of a request, to be written
+	// byte for byte
+	Body bool
+	// Suffix UniqueName added to the name of the file
+	Suffix string
+	// Requests the file holds, or whose body it holds
+	Requests []*OutputRequest
+}
+
+// OutputRequest -
+type OutputRequest struct {
+	Name string `json:"name"`
+	// Postman folders the request is in, outermost first
+	Folders []string `json:"folders,omitempty"`
+}
+
+// CollectionFiles - what ConvertCollectionFiles converted a collection into
+type CollectionFiles struct {
+	Files []*OutputFile
+	// Requests converted, in the order they were converted
+	Requests []*OutputRequest
+	// Number of folders converted
+	Folders int
+}
+
+// extension returns the file extension of the converted requests.
+func (o *Options) extension() string {
+	if o.Extension == "" {
+		return defaultExtension
+	}
+	return o.Extension
+}
+
+// flattenSeparator returns the separator between the folder names and the
+// request name of flattened files.
+func (o *Options) flattenSeparator() string {
+	if o.FlattenSeparator == "" {
+		return defaultFlattenSeparator
+	}
+	return o.FlattenSeparator
+}
+
+// ConvertCollectionFiles lays the collection out into files: a file
+// documenting the collection, the requests in a file each, or one per folder
+// with SingleFile, in directories mirroring the folders, and a .env file with
+// the collection variables. name, the name of the collection's output
+// directory, names the single file of the top level. Requests that fail to
+// convert are left out and returned as *ConvertError.
+func ConvertCollectionFiles(collection *PostmanCollection, name string, opts *Options) (*CollectionFiles, []error) {
+	opts = opts.orDefault()
+	converted := &CollectionFiles{}
+	root := &outputFolder{Name: name, CollectionAuth: collection.Auth, UsedFileNames: map[string]bool{}}
+	if opts.SharedAuth {
+		root.DefaultHeaders = sharedAuthHeaders(collection, opts)
+	}
+
+	// Keep the identity and documentation of the collection at the top of
+	// its output
+	infoFile := &OutputFile{Path: UniqueName(root.UsedFileNames, collectionInfoFileName) + opts.extension(), Content: CollectionHeader(&collection.Info)}
+
+	files, errs := convertFolder(collection.Items, root, collection.Auth, opts, converted)
+	converted.Files = append([]*OutputFile{infoFile}, files...)
+
+	// Add the variable defaults as a .env file httpYac loads for every request
+	defaults := CollectVariableDefaults(collection)
+	if opts.SharedAuth {
+		defaults = append(defaults, SharedAuthVariables(collection.Auth)...)
+	}
+	if opts.SortVariables {
+		defaults = SortVariables(defaults)
+	}
+	if len(defaults) > 0 {
+		variables := &PostmanEnvironment{Values: defaults}
+		converted.Files = append(converted.Files, &OutputFile{Path: ".env", Content: variables.Dotenv(opts.MaskSecrets)})
+	}
+
+	// Send the headers of the shared auth from the httpYac configuration
+	if len(root.DefaultHeaders) > 0 {
+		converted.Files = append(converted.Files, &OutputFile{Path: httpYacConfigFileName, Content: httpYacConfig(root.DefaultHeaders, opts)})
+	}
+	return converted, errs
+}
+
+// sharedAuthHeaders returns the headers the collection auth adds to the
+// requests using it, referencing the variables SharedAuthVariables defines.
+// There are none when a request not using the collection auth would pick
+// them up from the default headers, not setting them itself.
+func sharedAuthHeaders(collection *PostmanCollection, opts *Options) []*Header {
+	if collection.Auth == nil {
+		return nil
+	}
+	// The requests report what can't be converted themselves
+	quiet := *opts
+	quiet.Warnf = nil
+	shared := applyAuth(sharedAuth(collection.Auth), nil, &quiet).Headers
+	if len(shared) > 0 && !setsHeaders(collection.Items, collection.Auth, collection.Auth, nil, shared, &quiet) {
+		opts.warnf("Warning: collection %s has requests not using its auth, keeping the auth headers on the requests using it", collection.Info.Name)
+		return nil
+	}
+	return shared
+}
+
+// setsHeaders reports whether the requests of items not using the
+// collection auth set the headers themselves, overriding the default ones.
+func setsHeaders(items []*Item, inheritedAuth, collectionAuth *Auth, inheritedHeaders, headers []*Header, opts *Options) bool {
+	for _, item := range EnabledItems(items, opts) {
+		if item.Request != nil {
+			auth := ResolveAuth(item.Request.Auth, inheritedAuth)
+			if !reflect.DeepEqual(auth, collectionAuth) {
+				var requestHeaders []*Header
+				for _, header := range mergeHeaders(inheritedHeaders, item.Request.Header) {
+					if !header.Disabled {
+						requestHeaders = append(requestHeaders, header)
+					}
+				}
+				requestHeaders = applyAuth(auth, requestHeaders, opts).Headers
+				for _, header := range headers {
+					if findHeader(requestHeaders, header.Key) == nil {
+						return false
+					}
+				}
+			}
+		}
+		if !setsHeaders(item.Items, ResolveAuth(item.Auth, inheritedAuth), collectionAuth, InheritHeaders(inheritedHeaders, item), headers, opts) {
+			return false
+		}
+	}
+	return true
+}
+
+// httpYacConfig renders the .httpyac.json sending the headers with every
+// request of the collection.
+func httpYacConfig(headers []*Header, opts *Options) string {
+	defaultHeaders := map[string]string{}
+	for _, header := range headers {
+		defaultHeaders[header.Key] = opts.Config.substitute(header.Value)
+	}
+	data, _ := json.MarshalIndent(map[string]interface{}{"defaultHeaders": defaultHeaders}, "", "  ")
+	return string(data) + "\n"
+}
+
+// outputFolder is where convertFolder places the requests of a folder.
+type outputFolder struct {
+	// Directory relative to the output directory of the collection
+	Dir string
+	// Name of the single file of the folder, without extension
+	Name string
+	// Number of directories Dir is nested in
+	Depth int
+	// Postman folder converted, nil for the top level of the collection
+	Item *Item
+	// Whether the folder or one it is in is disabled
+	Disabled bool
+	// Headers the folder and the ones it is in add to its requests
+	Headers []*Header
+	// Names of the Postman folders the folder is, outermost first
+	Folders []string
+	// Auth of the collection, shared by the requests using it with SharedAuth
+	CollectionAuth *Auth
+	// Headers of the shared auth httpYac sends with every request
+	DefaultHeaders []*Header
+	// Sanitized path of the folder in flatten mode, prefixed to the file names
+	Prefix string
+	// Names already taken in Dir, so identically named items don't overwrite each other
+	UsedFileNames map[string]bool
+}
+
+// fileName returns the name of the file for an item of the folder, before
+// deduplication and without extension.
+func (f *outputFolder) fileName(name string, opts *Options) string {
+	if f.Prefix == "" {
+		return SanitizeName(name)
+	}
+	return truncateName(f.Prefix + opts.flattenSeparator() + SanitizeName(name))
+}
+
+// nested returns the output folder for the subfolder item, a directory of
+// its own or, in flatten mode, the same directory with a longer prefix.
+// Folders nested deeper than MaxDepth, or whose directory would get too
+// long, are flattened as well.
+func (f *outputFolder) nested(item *Item, usedDirNames map[string]bool, opts *Options) *outputFolder {
+	folders := append(slices.Clone(f.Folders), item.Name)
+	if !opts.Flatten && f.Prefix == "" {
+		name := SanitizeName(item.Name)
+		tooDeep := opts.MaxDepth > 0 && f.Depth >= opts.MaxDepth
+		if !tooDeep && len(filepath.Join(f.Dir, name)) <= maxFolderPathLength {
+			name = UniqueName(usedDirNames, name)
+			return &outputFolder{Dir: filepath.Join(f.Dir, name), Name: name, Depth: f.Depth + 1, Item: item, Disabled: f.Disabled || item.Disabled, Headers: InheritHeaders(f.Headers, item), Folders: folders, CollectionAuth: f.CollectionAuth, DefaultHeaders: f.DefaultHeaders, UsedFileNames: map[string]bool{}}
+		}
+	}
+	prefix := SanitizeName(item.Name)
+	if f.Prefix != "" {
+		prefix = f.Prefix + opts.flattenSeparator() + prefix
+	}
+	return &outputFolder{Dir: f.Dir, Name: truncateName(prefix), Depth: f.Depth, Item: item, Disabled: f.Disabled || item.Disabled, Headers: InheritHeaders(f.Headers, item), Folders: folders, CollectionAuth: f.CollectionAuth, DefaultHeaders: f.DefaultHeaders, Prefix: prefix, UsedFileNames: f.UsedFileNames}
+}
+
+// convertFolder converts the requests of items into the files of folder,
+// counts the converted requests and folders in converted and returns the
+// requests that could not be converted.
+func convertFolder(items []*Item, folder *outputFolder, inheritedAuth *Auth, opts *Options, converted *CollectionFiles) ([]*OutputFile, []error) {
+	var files []*OutputFile
+	var errs []error
+	var httpYacRequests []string
+	// Requests of httpYacRequests
+	var folderRequests []*OutputRequest
+	usedDirNames := map[string]bool{}
+	// Names of the body files of the requests put into the folder file
+	usedBodyNames := map[string]bool{}
+	extension := opts.extension()
+
+	// Leave out what Postman's runner skips, unless asked to keep it
+	items = EnabledItems(items, opts)
+
+	// Name the requests apart, the ones of the folder import each other or
+	// share its single file
+	names := requestIdentifiers(items, map[string]bool{})
+
+	// Run requests after the ones setting the variables they use
+	var dependencies map[*Item][]*Item
+	if opts.Chain {
+		items, dependencies = ChainRequests(items, inheritedAuth)
+	}
+
+	// Name the files up front so requests can import the ones they depend on
+	fileNames := map[*Item]string{}
+	// Suffixes UniqueName added to the file names
+	suffixes := map[*Item]string{}
+	for _, item := range items {
+		if item.Request != nil && !opts.SingleFile {
+			name := folder.fileName(templateFileName(item, len(fileNames)+1, opts), opts)
+			unique := UniqueName(folder.UsedFileNames, name)
+			fileNames[item] = unique + extension
+			suffixes[item] = strings.TrimPrefix(unique, name)
+		}
+	}
+
+	// Define the headers all requests of the folder send once, in the folder
+	// file or in a file the requests import
+	var commonHeaders []*Header
+	var commonImports []string
+	if opts.CommonHeaders {
+		commonHeaders = CommonHeaders(items)
+	}
+	if len(commonHeaders) > 0 && !opts.SingleFile {
+		commonFileName := UniqueName(folder.UsedFileNames, folder.fileName(commonHeadersFileName, opts)) + extension
+		files = append(files, &OutputFile{Path: filepath.Join(folder.Dir, commonFileName), Content: CommonHeaderVariables(commonHeaders, opts)})
+		commonImports = []string{"./" + commonFileName}
+	}
+
+	// Iterate through each request in the collection and give it a separate .http file
+	for _, item := range items {
+		// First level request in collection
+		if item.Request != nil {
+			ctx := &RequestContext{Auth: ResolveAuth(item.Request.Auth, inheritedAuth), Disabled: folder.Disabled, Headers: folder.Headers, CommonHeaders: commonHeaders, Name: names[item]}
+			ctx.Imports = append(ctx.Imports, commonImports...)
+			if opts.SingleFile {
+				name := folder.fileName(templateFileName(item, len(usedBodyNames)+1, opts), opts)
+				unique := UniqueName(usedBodyNames, name)
+				ctx.BodyFileName = unique + bodyFileExtension
+				suffixes[item] = strings.TrimPrefix(unique, name)
+			} else {
+				ctx.BodyFileName = strings.TrimSuffix(fileNames[item], extension) + bodyFileExtension
+			}
+			ctx.SharedAuth = opts.SharedAuth && folder.CollectionAuth != nil && reflect.DeepEqual(ctx.Auth, folder.CollectionAuth)
+			ctx.DefaultHeaders = folder.DefaultHeaders
+			for _, dependency := range dependencies[item] {
+				ctx.Refs = append(ctx.Refs, names[dependency])
+				if !opts.SingleFile {
+					ctx.Imports = append(ctx.Imports, "./"+fileNames[dependency])
+				}
+			}
+
+			// Create an HTTPYac request and add environment variables
+			httpYacRequest, err := ConvertRequest(item, ctx, opts)
+			if err != nil {
+				errs = append(errs, &ConvertError{Request: item.Name, Err: err})
+				continue
+			}
+			requests := []*OutputRequest{{Name: item.Name, Folders: folder.Folders}}
+			converted.Requests = append(converted.Requests, requests...)
+			if ctx.BodyData != nil {
+				files = append(files, &OutputFile{Path: filepath.Join(folder.Dir, ctx.BodyFileName), Content: string(ctx.BodyData), Body: true, Suffix: suffixes[item], Requests: requests})
+			}
+
+			// Collect the request to write it together with the rest of the folder
+			if opts.SingleFile {
+				httpYacRequests = append(httpYacRequests, httpYacRequest)
+				folderRequests = append(folderRequests, requests...)
+				continue
+			}
+			files = append(files, &OutputFile{Path: filepath.Join(folder.Dir, fileNames[item]), Content: httpYacRequest, Suffix: suffixes[item], Requests: requests})
+		}
+
+		// Subfolder request in collection
+		if len(item.Items) > 0 {
+			converted.Folders++
+			nestedFolder := folder.nested(item, usedDirNames, opts)
+			nestedFiles, nestedErrs := convertFolder(item.Items, nestedFolder, ResolveAuth(item.Auth, inheritedAuth), opts, converted)
+			files = append(files, nestedFiles...)
+			errs = append(errs, nestedErrs...)
+		}
+	}
+
+	// Put the requests of the folder into a single .http file named after it
+	if len(httpYacRequests) > 0 {
+		// Flattened subfolders share the directory, and the names in it
+		folderName := UniqueName(folder.UsedFileNames, folder.Name)
+		// A region without request line defines the variables for the file
+		if len(commonHeaders) > 0 {
+			httpYacRequests = append([]string{CommonHeaderVariables(commonHeaders, opts)}, httpYacRequests...)
+		}
+		if folder.Item != nil {
+			httpYacRequests[0] = FolderBanner(folder.Item) + httpYacRequests[0]
+		}
+		files = append(files, &OutputFile{Path: filepath.Join(folder.Dir, folderName+extension), Content: JoinRequests(httpYacRequests), Suffix: strings.TrimPrefix(folderName, folder.Name), Requests: folderRequests})
+	}
+	return files, errs
+}
+
+// requestFileName returns the sanitized name of a request, or request-<index>
+// for names that leave nothing usable, like empty ones or ones made only of
+// special characters.
+func requestFileName(name string, index int) string {
+	sanitized := SanitizeName(name)
+	if strings.Trim(sanitized, "_") == "" {
+		return fmt.Sprintf("request-%d", index)
+	}
+	return sanitized
+}
+
+// fileNameTemplatePattern matches the placeholders of FileNameTemplate.
+var fileNameTemplatePattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateFileNameTemplate reports the placeholders of the template that
+// can't be filled in.
+func ValidateFileNameTemplate(template string) error {
+	for _, placeholder := range fileNameTemplatePattern.FindAllString(template, -1) {
+		switch placeholder {
+		case "{name}", "{method}", "{host}":
+		default:
+			return fmt.Errorf("unknown placeholder %s, use {name}, {method} or {host}", placeholder)
+		}
+	}
+	if strings.ContainsAny(fileNameTemplatePattern.ReplaceAllString(template, ""), "{}") {
+		return errors.New("unbalanced braces")
+	}
+	return nil
+}
+
+// templateFileName returns the sanitized file name of a request, filled in
+// from FileNameTemplate when set, see requestFileName.
+func templateFileName(item *Item, index int, opts *Options) string {
+	if opts.FileNameTemplate == "" {
+		return requestFileName(item.Name, index)
+	}
+	// A name that leaves nothing usable is filled in as request-<index>,
+	// rather than leaving only the rest of the template
+	replacer := strings.NewReplacer("{name}", requestFileName(item.Name, index), "{method}", item.Request.Method, "{host}", RequestHost(item.Request))
+	return requestFileName(replacer.Replace(opts.FileNameTemplate), index)
+}
+
+// UniqueName returns name, or name suffixed with -2, -3, ... when it has
+// been used before. Names are compared case-insensitively, as they end up
+// on file systems that may not tell them apart.
+func UniqueName(used map[string]bool, name string) string {
+	unique := name
+	for n := 2; used[strings.ToLower(unique)]; n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+// SanitizeName turns a Postman name into a file or directory name that is
+// valid on all platforms.
+func SanitizeName(fileName string) string {
+	sanitizedFileName := strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '/', '\\', '?', '*', '<', '>', '|', '"':
+			return '_'
+		}
+		if unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, fileName)
+
+	// Collapse the runs of underscores left by replaced characters
+	sanitizedFileName = underscoreRunPattern.ReplaceAllString(sanitizedFileName, "_")
+
+	// Windows drops trailing spaces and dots, and leading ones hide files elsewhere
+	sanitizedFileName = strings.Trim(sanitizedFileName, " .")
+	if sanitizedFileName == "" {
+		return "_"
+	}
+	return truncateName(sanitizedFileName)
+}
+
+// truncateName keeps names within path limits, appending a hash of the full
+// name to keep truncated names apart.
+func truncateName(name string) string {
+	if len(name) <= maxNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha1.Sum([]byte(name)))[:8]
+	truncated := name[:maxNameLength-len(hash)-1]
+	for !utf8.ValidString(truncated) {
+		truncated = truncated[:len(truncated)-1]
+	}
+	return strings.TrimRight(truncated, " .") + "-" + hash
+}
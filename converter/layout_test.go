@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertCollectionFilesRequestNames(t *testing.T) {
+	collection, err := ParseCollection([]byte(duplicateNamesCollection))
+	if err != nil {
+		t.Fatalf("ParseCollection() error = %v", err)
+	}
+	converted, errs := ConvertCollectionFiles(collection, "dup", &Options{Chain: true, SingleFile: true})
+	if len(errs) > 0 {
+		t.Fatalf("ConvertCollectionFiles() errors = %v", errs)
+	}
+
+	// Names only need to be apart within the file they are in
+	want := map[string][]string{
+		"dup.http":           {"# @name get", "# @name get_2", "# @ref get"},
+		"Folder/Folder.http": {"# @name get"},
+	}
+	for _, file := range converted.Files {
+		lines, ok := want[file.Path]
+		if !ok {
+			continue
+		}
+		delete(want, file.Path)
+		var got []string
+		for _, line := range strings.Split(file.Content, "\n") {
+			if strings.HasPrefix(line, "# @") {
+				got = append(got, line)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(lines, "\n") {
+			t.Errorf("%s directives = %q, want %q", file.Path, got, lines)
+		}
+	}
+	for path := range want {
+		t.Errorf("missing file %s", path)
+	}
+}
+
+func TestConvertCollectionFilesSharedAuth(t *testing.T) {
+	collection, err := ParseCollection([]byte(`{
+		"info": {"name": "shared", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "s3cret"}]},
+		"item": [
+			{"name": "A", "request": {"method": "GET", "url": "{{baseUrl}}/a"}},
+			{"name": "B", "request": {"method": "GET", "url": "{{baseUrl}}/b", "auth": {"type": "basic", "basic": [{"key": "username", "value": "u"}, {"key": "password", "value": "p"}]}}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseCollection() error = %v", err)
+	}
+	converted, errs := ConvertCollectionFiles(collection, "shared", &Options{SharedAuth: true})
+	if len(errs) > 0 {
+		t.Fatalf("ConvertCollectionFiles() errors = %v", errs)
+	}
+
+	want := map[string]string{
+		".env":          "auth_token=s3cret\n",
+		".httpyac.json": "{\n  \"defaultHeaders\": {\n    \"Authorization\": \"Bearer {{auth_token}}\"\n  }\n}\n",
+		"A.http":        "# @name a\nGET {{baseUrl}}/a\n",
+		"B.http":        "# @name b\nGET {{baseUrl}}/b\nAuthorization: Basic u:p\n",
+	}
+	for _, file := range converted.Files {
+		if content, ok := want[file.Path]; ok {
+			if file.Content != content {
+				t.Errorf("%s = %q, want %q", file.Path, file.Content, content)
+			}
+			delete(want, file.Path)
+		}
+	}
+	for path := range want {
+		t.Errorf("missing file %s", path)
+	}
+}
+
+func TestConvertCollectionFilesSharedAuthWithoutAuth(t *testing.T) {
+	collection, err := ParseCollection([]byte(`{
+		"info": {"name": "shared", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "s3cret"}]},
+		"item": [
+			{"name": "A", "request": {"method": "GET", "url": "{{baseUrl}}/a"}},
+			{"name": "Public", "request": {"method": "GET", "url": "{{baseUrl}}/public", "auth": {"type": "noauth"}}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseCollection() error = %v", err)
+	}
+	converted, _ := ConvertCollectionFiles(collection, "shared", &Options{SharedAuth: true})
+
+	// The public request must not pick the default headers up
+	for _, file := range converted.Files {
+		switch file.Path {
+		case ".httpyac.json":
+			t.Errorf("unexpected %s", file.Path)
+		case "A.http":
+			if !strings.Contains(file.Content, "Authorization: Bearer {{auth_token}}\n") {
+				t.Errorf("A.http = %q, want the shared auth header", file.Content)
+			}
+		}
+	}
+}
+
+func TestTemplateFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		item     string
+		want     string
+	}{
+		{name: "no template", item: "Get Users", want: "Get Users"},
+		{name: "method and name", template: "{method}_{name}", item: "Get Users", want: "GET_Get Users"},
+		{name: "empty name", template: "{method}_{name}", item: "", want: "GET_request-3"},
+		{name: "symbols only name", template: "{method}_{name}", item: "???", want: "GET_request-3"},
+		{name: "empty name without template", item: "", want: "request-3"},
+		{name: "host", template: "{host}-{name}", item: "Get", want: "api.example.com-Get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &Item{Name: tt.item, Request: &Request{Method: "GET", URL: []byte(`"https://api.example.com/users"`)}}
+			if got := templateFileName(item, 3, &Options{FileNameTemplate: tt.template}); got != tt.want {
+				t.Errorf("templateFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// QueryParam -
+type QueryParam struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// PathVariable -
+type PathVariable struct {
+	Key   string        `json:"key"`
+	Value VariableValue `json:"value"`
+}
+
+// URL -
+type URL struct {
+	Raw      string          `json:"raw"`
+	Protocol string          `json:"protocol"`
+	Host     URLSegments     `json:"host"`
+	Port     string          `json:"port"`
+	Path     URLSegments     `json:"path"`
+	Query    []*QueryParam   `json:"query"`
+	Variable []*PathVariable `json:"variable"`
+}
+
+// Header -
+type Header struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value"`
+	Disabled    bool        `json:"disabled"`
+	Description Description `json:"description"`
+}
+
+// URLEncodedParam -
+type URLEncodedParam struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// FormDataParam -
+type FormDataParam struct {
+	Key         string          `json:"key"`
+	Value       string          `json:"value"`
+	Type        string          `json:"type"`
+	Src         json.RawMessage `json:"src"`
+	ContentType string          `json:"contentType"`
+	Disabled    bool            `json:"disabled"`
+}
+
+// Sources returns the file paths of a file form field. Postman stores a single
+// path as a string and multiple selected files as an array.
+func (p *FormDataParam) Sources() []string {
+	var src string
+	if err := json.Unmarshal(p.Src, &src); err == nil {
+		if src == "" {
+			return nil
+		}
+		return []string{src}
+	}
+
+	var srcs []string
+	json.Unmarshal(p.Src, &srcs)
+	return srcs
+}
+
+// GraphQL -
+type GraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables"`
+}
+
+// RawOptions -
+type RawOptions struct {
+	Language string `json:"language"`
+}
+
+// BodyOptions -
+type BodyOptions struct {
+	Raw *RawOptions `json:"raw"`
+}
+
+// Body -
+type Body struct {
+	Raw        string             `json:"raw"`
+	Mode       string             `json:"mode"`
+	URLEncoded []*URLEncodedParam `json:"urlencoded"`
+	FormData   []*FormDataParam   `json:"formdata"`
+	GraphQL    *GraphQL           `json:"graphql"`
+	Options    *BodyOptions       `json:"options"`
+	File       *BodyFile          `json:"file"`
+}
+
+// BodyFile -
+type BodyFile struct {
+	Src     string `json:"src"`
+	Content string `json:"content"`
+}
+
+// AuthAttribute -
+type AuthAttribute struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// Auth -
+type Auth struct {
+	Type   string         `json:"type"`
+	Bearer AuthAttributes `json:"bearer"`
+	Basic  AuthAttributes `json:"basic"`
+	APIKey AuthAttributes `json:"apikey"`
+	OAuth1 AuthAttributes `json:"oauth1"`
+	OAuth2 AuthAttributes `json:"oauth2"`
+	Digest AuthAttributes `json:"digest"`
+	NTLM   AuthAttributes `json:"ntlm"`
+	AWSv4  AuthAttributes `json:"awsv4"`
+	Hawk   AuthAttributes `json:"hawk"`
+	// Akamai EdgeGrid
+	EdgeGrid AuthAttributes `json:"edgegrid"`
+}
+
+// Description -
+type Description string
+
+// UnmarshalJSON accepts both the plain string form and the
+// {"content": ..., "type": ...} object form of Postman descriptions.
+func (d *Description) UnmarshalJSON(data []byte) error {
+	var content string
+	if err := json.Unmarshal(data, &content); err == nil {
+		*d = Description(content)
+		return nil
+	}
+
+	var object struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*d = Description(object.Content)
+	return nil
+}
+
+// Request -
+type Request struct {
+	Method      string          `json:"method"`
+	URL         json.RawMessage `json:"url"`
+	Header      Headers         `json:"header"`
+	Body        json.RawMessage `json:"body"`
+	Auth        *Auth           `json:"auth"`
+	Description Description     `json:"description"`
+}
+
+// Script -
+type Script struct {
+	Type string      `json:"type"`
+	Exec ScriptLines `json:"exec"`
+}
+
+// Event -
+type Event struct {
+	Listen string  `json:"listen"`
+	Script *Script `json:"script"`
+}
+
+// Response - a saved example response of a request
+type Response struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	Code   int     `json:"code"`
+	Header Headers `json:"header"`
+	Body   string  `json:"body"`
+}
+
+// Item -
+type Item struct {
+	Name        string                   `json:"name"`
+	Request     *Request                 `json:"request"`
+	Items       []*Item                  `json:"item"`
+	Auth        *Auth                    `json:"auth"`
+	Description Description              `json:"description"`
+	Events      []*Event                 `json:"event"`
+	Variables   []*EnvironmentItem       `json:"variable"`
+	ID          string                   `json:"id"`
+	PostmanID   string                   `json:"_postman_id"`
+	Behavior    *ProtocolProfileBehavior `json:"protocolProfileBehavior"`
+	Disabled    bool                     `json:"disabled"`
+	Responses   []*Response              `json:"response"`
+	// Headers a folder adds to the requests in it
+	Header Headers `json:"header"`
+	// IDs of the requests and folders in the order Postman shows them
+	Order        []string `json:"order"`
+	FoldersOrder []string `json:"folders_order"`
+}
+
+// ProtocolProfileBehavior - request settings, unset ones follow the Postman defaults
+type ProtocolProfileBehavior struct {
+	FollowRedirects *bool `json:"followRedirects"`
+	StrictSSL       *bool `json:"strictSSL"`
+	DisableCookies  *bool `json:"disableCookies"`
+	// Send the body of methods Postman strips it from otherwise
+	DisableBodyPruning *bool `json:"disableBodyPruning"`
+}
+
+// bodyPrunedMethods are the methods Postman sends without body unless body
+// pruning is disabled.
+var bodyPrunedMethods = []string{"GET", "HEAD", "COPY", "PURGE", "UNLOCK"}
+
+// PrunesBody reports whether Postman leaves the body out of a request with
+// the given method.
+func (b *ProtocolProfileBehavior) PrunesBody(method string) bool {
+	if b != nil && b.DisableBodyPruning != nil && *b.DisableBodyPruning {
+		return false
+	}
+	return slices.Contains(bodyPrunedMethods, strings.ToUpper(method))
+}
+
+// Directives returns the httpYac metadata directives matching the settings.
+func (b *ProtocolProfileBehavior) Directives() []string {
+	if b == nil {
+		return nil
+	}
+	var directives []string
+	if b.FollowRedirects != nil && !*b.FollowRedirects {
+		directives = append(directives, "no-redirect")
+	}
+	if b.StrictSSL != nil && !*b.StrictSSL {
+		directives = append(directives, "no-reject-unauthorized")
+	}
+	if b.DisableCookies != nil && *b.DisableCookies {
+		directives = append(directives, "no-cookie-jar")
+	}
+	return directives
+}
+
+// Info -
+type Info struct {
+	Name        string      `json:"name"`
+	Description Description `json:"description"`
+	Schema      string      `json:"schema"`
+}
+
+// PostmanCollection -
+type PostmanCollection struct {
+	Info      Info               `json:"info"`
+	Items     []*Item            `json:"item"`
+	Auth      *Auth              `json:"auth"`
+	Variables []*EnvironmentItem `json:"variable"`
+	// IDs of the requests and folders in the order Postman shows them
+	Order        []string `json:"order"`
+	FoldersOrder []string `json:"folders_order"`
+}
+
+// EnvironmentItem -
+type EnvironmentItem struct {
+	Key      string        `json:"key"`
+	Value    VariableValue `json:"value"`
+	Type     string        `json:"type"`
+	Enabled  bool          `json:"enabled"`
+	Disabled bool          `json:"disabled"`
+}
+
+// IsEnabled reports whether the value is in use. Environments toggle values
+// with "enabled", collection variables with "disabled".
+func (i *EnvironmentItem) IsEnabled() bool {
+	return i.Enabled && !i.Disabled
+}
+
+// UnmarshalJSON treats items without an "enabled" flag as enabled, which is
+// how Postman reads them.
+func (i *EnvironmentItem) UnmarshalJSON(data []byte) error {
+	type environmentItem EnvironmentItem
+	item := environmentItem{Enabled: true}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	*i = EnvironmentItem(item)
+	return nil
+}
+
+// VariableValue -
+type VariableValue string
+
+// UnmarshalJSON accepts any JSON value, as collection variables may carry
+// numbers or booleans where environments carry strings. Objects and arrays
+// are kept as their JSON text.
+func (v *VariableValue) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case nil:
+		*v = ""
+	case string:
+		*v = VariableValue(value)
+	default:
+		compact := bytes.Buffer{}
+		if err := json.Compact(&compact, data); err != nil {
+			return err
+		}
+		*v = VariableValue(compact.String())
+	}
+	return nil
+}
+
+// PostmanEnvironment -
+type PostmanEnvironment struct {
+	Name   string             `json:"name"`
+	Values []*EnvironmentItem `json:"values"`
+}
+
+func (e *PostmanEnvironment) String() string {
+	return e.Dotenv(false)
+}
+
+// Variables returns the enabled values by key, leaving secrets out with
+// maskSecrets.
+func (e *PostmanEnvironment) Variables(maskSecrets bool) map[string]string {
+	variables := map[string]string{}
+	for _, v := range e.Values {
+		if !v.IsEnabled() || (maskSecrets && v.Type == "secret") {
+			continue
+		}
+		variables[v.Key] = string(v.Value)
+	}
+	return variables
+}
+
+// Secrets returns the enabled values of secret type by key.
+func (e *PostmanEnvironment) Secrets() map[string]string {
+	secrets := map[string]string{}
+	for _, v := range e.Values {
+		if v.IsEnabled() && v.Type == "secret" {
+			secrets[v.Key] = string(v.Value)
+		}
+	}
+	return secrets
+}
+
+// Dotenv renders the enabled values as a .env file. With maskSecrets, values
+// of secret type are left out and only their keys kept as comments, so the
+// file can be committed without leaking credentials.
+func (e *PostmanEnvironment) Dotenv(maskSecrets bool) string {
+	sb := strings.Builder{}
+	for _, v := range e.Values {
+		if !v.IsEnabled() {
+			continue
+		}
+		if maskSecrets && v.Type == "secret" {
+			sb.WriteString(fmt.Sprintf("# %s= (secret, set the value locally)\n", v.Key))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s=%s\n", v.Key, v.Value))
+	}
+	return sb.String()
+}
@@ -0,0 +1,39 @@
+package converter
+
+import "testing"
+
+func TestParseCollectionVariableValues(t *testing.T) {
+	data := []byte(`{
+		"info": {"name": "vars", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [],
+		"variable": [
+			{"key": "name", "value": "api"},
+			{"key": "retries", "value": 3},
+			{"key": "debug", "value": true},
+			{"key": "empty", "value": null},
+			{"key": "cfg", "value": {"a": 1, "b": [true, "x"]}},
+			{"key": "ids", "value": [1, 2]}
+		]
+	}`)
+	collection, err := ParseCollection(data)
+	if err != nil {
+		t.Fatalf("ParseCollection() error = %v", err)
+	}
+
+	want := map[string]string{
+		"name":    "api",
+		"retries": "3",
+		"debug":   "true",
+		"empty":   "",
+		"cfg":     `{"a":1,"b":[true,"x"]}`,
+		"ids":     "[1,2]",
+	}
+	if len(collection.Variables) != len(want) {
+		t.Fatalf("got %d variables, want %d", len(collection.Variables), len(want))
+	}
+	for _, variable := range collection.Variables {
+		if got := string(variable.Value); got != want[variable.Key] {
+			t.Errorf("variable %s = %q, want %q", variable.Key, got, want[variable.Key])
+		}
+	}
+}
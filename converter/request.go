@@ -0,0 +1,470 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FolderBanner returns the comments opening the region of a folder in
+// combined output: a banner with its name and its description.
+func FolderBanner(folder *Item) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("# ###### %s ######\n", folder.Name))
+	writeComment(&sb, string(folder.Description))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// CollectionHeader returns the comments documenting the collection: its name,
+// its description and the schema it was exported with.
+func CollectionHeader(info *Info) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("# %s\n", info.Name))
+	if description := strings.TrimSpace(string(info.Description)); description != "" {
+		sb.WriteString("#\n")
+		writeComment(&sb, description)
+	}
+	if info.Schema != "" {
+		sb.WriteString("#\n")
+		sb.WriteString(fmt.Sprintf("# Converted from %s\n", info.Schema))
+	}
+	return sb.String()
+}
+
+// JoinRequests concatenates requests into the content of a single
+// .http file, separating them with the ### delimiter httpYac splits on.
+func JoinRequests(httpYacRequests []string) string {
+	blocks := make([]string, 0, len(httpYacRequests))
+	for _, httpYacRequest := range httpYacRequests {
+		// Keep the empty last line of a body ending with a line break
+		if !strings.HasSuffix(httpYacRequest, "\n") {
+			httpYacRequest += "\n"
+		}
+		blocks = append(blocks, httpYacRequest)
+	}
+	return strings.Join(blocks, "###\n\n")
+}
+
+// RequestIdentifier slugifies a Postman item name into a name httpYac can
+// reference from scripts and variables, e.g. "Get User (v2)" -> "get_user_v2".
+func RequestIdentifier(name string) string {
+	sb := strings.Builder{}
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if underscore && sb.Len() > 0 {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(r)
+			underscore = false
+			continue
+		}
+		underscore = true
+	}
+
+	identifier := sb.String()
+	if identifier == "" {
+		return "request"
+	}
+	if r, _ := utf8.DecodeRuneInString(identifier); unicode.IsDigit(r) {
+		identifier = "_" + identifier
+	}
+	return identifier
+}
+
+// requestIdentifiers names the requests of items after RequestIdentifier,
+// suffixing the names taken in used with _2, _3, ... so that # @name and
+// # @ref stay unambiguous within the file they end up in.
+func requestIdentifiers(items []*Item, used map[string]bool) map[*Item]string {
+	names := map[*Item]string{}
+	for _, item := range items {
+		if item.Request == nil {
+			continue
+		}
+		name := RequestIdentifier(item.Name)
+		unique := name
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s_%d", name, n)
+		}
+		used[unique] = true
+		names[item] = unique
+	}
+	return names
+}
+
+// parseURL reads a request URL given either as a URL object or as a plain
+// string, falling back to the raw JSON for anything else.
+func parseURL(data json.RawMessage) URL {
+	var url URL
+	var rawURL string
+	if err := json.Unmarshal(data, &rawURL); err == nil {
+		url.Raw = rawURL
+	} else if err := json.Unmarshal(data, &url); err != nil {
+		url.Raw = string(data)
+	}
+	return url
+}
+
+// ConvertRequest renders a Postman request item as an httpYac request.
+func ConvertRequest(item *Item, ctx *RequestContext, opts *Options) (string, error) {
+	opts = opts.orDefault()
+	if ctx == nil {
+		ctx = &RequestContext{}
+	}
+	request := item.Request
+
+	// Parse the URL
+	url := parseURL(request.URL)
+
+	// Headers toggled off in Postman are not sent, so leave them out. A set
+	// Content-Length goes stale once the body is rendered, httpYac computes
+	// it from the body it sends instead
+	var headers []*Header
+	for _, header := range mergeHeaders(ctx.Headers, request.Header) {
+		if !header.Disabled && !strings.EqualFold(strings.TrimSpace(header.Key), "Content-Length") {
+			headers = append(headers, header)
+		}
+	}
+	headers = withCommonHeaders(headers, ctx.CommonHeaders)
+	headers = mergeCookieHeaders(headers)
+
+	// The headers the conversion adds come after the request's own ones, in
+	// a fixed order: the auth header, then the Content-Type of the body
+	auth := ctx.Auth
+	if ctx.SharedAuth {
+		auth = sharedAuth(auth)
+	}
+	applied := applyAuth(auth, headers, opts)
+	headers = withoutDefaultHeaders(applied.Headers, ctx.DefaultHeaders)
+
+	// Render the body according to its mode
+	var renderedBody string
+	if request.Body != nil {
+		var body Body
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			body.Raw = string(request.Body)
+		}
+
+		// Postman does not send the body of GET requests and the like unless
+		// told to, empty bodies are left out quietly
+		pruned := item.Behavior.PrunesBody(request.Method)
+		data, binary := binaryBody(&body, headers)
+		switch {
+		case binary && pruned:
+			opts.warnf("Warning: request %s has a body Postman does not send with %s, leaving it out", item.Name, request.Method)
+		case binary && ctx.BodyFileName != "":
+			// Keep binary data out of the .http file, in the file the caller named
+			renderedBody = "< ./" + ctx.BodyFileName
+			ctx.BodyData = data
+		default:
+			if binary {
+				opts.warnf("Warning: request %s has a binary body, keeping it inline", item.Name)
+			}
+			var bodyHeaders []*Header
+			renderedBody, bodyHeaders = renderBody(&body, headers, opts)
+			if pruned && renderedBody != "" {
+				opts.warnf("Warning: request %s has a body Postman does not send with %s, leaving it out", item.Name, request.Method)
+				renderedBody = ""
+			} else {
+				headers = bodyHeaders
+			}
+		}
+	}
+
+	sb := strings.Builder{}
+
+	// Apply the configured substitutions, then replace Postman dynamic
+	// variables with what httpYac understands
+	dynamic := &dynamicVariableTranslator{}
+	rewrite := func(s string) string {
+		return dynamic.translate(opts.Config.substitute(s))
+	}
+
+	// Send the request the pre-request script sends as a request of its own
+	// run first, what else the script does is kept for manual porting
+	name := ctx.Name
+	if name == "" {
+		name = RequestIdentifier(item.Name)
+	}
+	refs := ctx.Refs
+	preRequestScript := eventScript(item.Events, "prerequest")
+	if sent, ok := translateSendRequest(preRequestScript); ok {
+		sentName := sentRequestName(name)
+		sb.WriteString(renderSentRequest(sentName, sent, rewrite))
+		sb.WriteString("###\n\n")
+		refs = append([]string{sentName}, refs...)
+		preRequestScript = sent.Rest
+	}
+
+	// Make the requests this one depends on available to reference, in
+	// its own region rather than the one of the request sent before it
+	for _, file := range ctx.Imports {
+		sb.WriteString(fmt.Sprintf("# @import %s\n", file))
+	}
+
+	// Name the request so that other requests can reference it
+	sb.WriteString(fmt.Sprintf("# @name %s\n", name))
+
+	// Run the requests setting the variables this one uses first
+	for _, ref := range refs {
+		sb.WriteString(fmt.Sprintf("# @ref %s\n", ref))
+	}
+
+	// Keep the request settings that differ from the defaults of both tools
+	for _, directive := range item.Behavior.Directives() {
+		sb.WriteString(fmt.Sprintf("# @%s\n", directive))
+	}
+
+	// Keep the documentation as comments above the request
+	description := request.Description
+	if description == "" {
+		description = item.Description
+	}
+	writeComment(&sb, string(description))
+
+	// Document the saved example responses, above the request where they
+	// can't be taken for part of its body
+	for i, response := range item.Responses {
+		if i > 0 {
+			sb.WriteString("#\n")
+		}
+		writeComment(&sb, renderExampleResponse(response))
+	}
+
+	// Explain the auth that could not be carried over
+	for _, note := range applied.Notes {
+		writeComment(&sb, note)
+	}
+
+	// Define the variables the auth needs for this request
+	for _, variable := range applied.Variables {
+		sb.WriteString(fmt.Sprintf("@%s = %s\n", variable.Name, variable.Value))
+	}
+
+	// Apply the auth httpYac has no directive for through scripts
+	for _, script := range applied.Scripts {
+		sb.WriteString(script)
+		sb.WriteString("\n")
+	}
+
+	// Keep the variables Postman scopes to this request
+	for _, variable := range item.Variables {
+		if variable.IsEnabled() && variable.Key != "" {
+			sb.WriteString(fmt.Sprintf("@%s = %s\n", variable.Key, variable.Value))
+		}
+	}
+
+	// Keep the pre-request script for manual porting, running it as is would
+	// fail on the pm API httpYac does not provide
+	if preRequestScript != "" {
+		sb.WriteString(renderPreRequestScript(preRequestScript))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("%s %s\n", request.Method, rewrite(buildRequestURL(&url, applied.Query))))
+	for _, header := range headers {
+		if opts.HeaderDescriptions {
+			writeComment(&sb, string(header.Description))
+		}
+		key := strings.TrimSpace(header.Key)
+		if opts.NormalizeHeaders {
+			key = textproto.CanonicalMIMEHeaderKey(key)
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s\n", key, rewrite(header.Value)))
+	}
+
+	// A single blank line separates the headers from the body, requests
+	// without one end with their headers. The body is written as stored and
+	// its last line ended, so a line break the body ends with stays as an
+	// empty last line
+	bodyEnd := -1
+	if renderedBody != "" {
+		sb.WriteString("\n")
+		sb.WriteString(guardRequestSeparators(rewrite(renderedBody)))
+		sb.WriteString("\n")
+		bodyEnd = sb.Len()
+	}
+
+	// Separate what follows by a blank line of its own, an empty last line
+	// of the body belongs to the body
+	writeSeparator := func() {
+		if sb.Len() == bodyEnd {
+			sb.WriteString("\n")
+			return
+		}
+		writeBlankLine(&sb)
+	}
+
+	// Check the response the way the Postman tests do and capture the values
+	// the test script stores in variables
+	if testScript := eventScript(item.Events, "test"); testScript != "" {
+		translation := translateTestScript(testScript)
+		if len(translation.Assertions) > 0 {
+			writeSeparator()
+			sb.WriteString(renderAssertions(translation.Assertions))
+		}
+		if script := renderTestScript(translation); script != "" {
+			writeSeparator()
+			sb.WriteString(script)
+		}
+	}
+
+	if len(dynamic.unknown) > 0 {
+		opts.warnf("Warning: request %s uses Postman dynamic variables without httpYac equivalent: %s", item.Name, strings.Join(dynamic.unknown, ", "))
+	}
+
+	httpYacRequest := sb.String()
+	if item.Disabled || ctx.Disabled {
+		httpYacRequest = commentOut(httpYacRequest)
+	}
+	return httpYacRequest, nil
+}
+
+// requestSeparatorPattern matches the body lines httpYac would take for the
+// ### separator starting the next request, which may be indented.
+var requestSeparatorPattern = regexp.MustCompile(`(?m)^([ \t]*)###`)
+
+// guardRequestSeparators writes the ### starting a body line as a script
+// expression, which httpYac only evaluates to ### when sending the body.
+// The indentation before it is kept.
+func guardRequestSeparators(body string) string {
+	return requestSeparatorPattern.ReplaceAllString(body, `${1}{{"###"}}`)
+}
+
+// commentOut turns a request disabled in Postman into comments, so httpYac
+// doesn't send it but it is still at hand.
+func commentOut(httpYacRequest string) string {
+	sb := strings.Builder{}
+	sb.WriteString("# disabled in Postman\n")
+	for _, line := range strings.Split(strings.TrimRight(httpYacRequest, "\n"), "\n") {
+		if line == "" {
+			sb.WriteString("#\n")
+			continue
+		}
+		sb.WriteString("# " + line + "\n")
+	}
+	return sb.String()
+}
+
+// renderExampleResponse renders a saved example response the way it would
+// arrive, headed by its name.
+func renderExampleResponse(response *Response) string {
+	sb := strings.Builder{}
+	name := response.Name
+	if name == "" {
+		name = "response"
+	}
+	sb.WriteString(fmt.Sprintf("Example %s:\n", name))
+	status := strings.TrimSpace(fmt.Sprintf("%d %s", response.Code, response.Status))
+	if response.Code == 0 {
+		status = response.Status
+	}
+	sb.WriteString(strings.TrimSpace("HTTP/1.1 "+status) + "\n")
+	for _, header := range response.Header {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", strings.TrimSpace(header.Key), header.Value))
+	}
+	if response.Body != "" {
+		body := response.Body
+		if isJSONContentType(response.Header) {
+			body = indentJSON(body)
+		}
+		sb.WriteString("\n" + body)
+	}
+	return sb.String()
+}
+
+// writeBlankLine ends what has been written so far with an empty line.
+func writeBlankLine(sb *strings.Builder) {
+	switch written := sb.String(); {
+	case strings.HasSuffix(written, "\n\n"):
+	case strings.HasSuffix(written, "\n"):
+		sb.WriteString("\n")
+	default:
+		sb.WriteString("\n\n")
+	}
+}
+
+// writeComment writes text as '#' comment lines, line by line.
+func writeComment(sb *strings.Builder, text string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			sb.WriteString("#\n")
+			continue
+		}
+		sb.WriteString("# " + line + "\n")
+	}
+}
+
+// findHeader returns the first header with the given key. Keys compare
+// case-insensitively and without surrounding whitespace, as HTTP does.
+func findHeader(headers []*Header, key string) *Header {
+	for _, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header.Key), key) {
+			return header
+		}
+	}
+	return nil
+}
+
+// withoutDefaultHeaders leaves out the headers httpYac sends by default with
+// the same value anyway.
+func withoutDefaultHeaders(headers, defaults []*Header) []*Header {
+	if len(defaults) == 0 {
+		return headers
+	}
+	kept := make([]*Header, 0, len(headers))
+	for _, header := range headers {
+		if defaultHeader := findHeader(defaults, strings.TrimSpace(header.Key)); defaultHeader == nil || defaultHeader.Value != header.Value {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+// mergeCookieHeaders joins the cookies of repeated Cookie headers into the
+// first one, as a request may carry only one Cookie header. Postman merges
+// them the same way before sending.
+func mergeCookieHeaders(headers []*Header) []*Header {
+	var cookie *Header
+	merged := make([]*Header, 0, len(headers))
+	for _, header := range headers {
+		if !strings.EqualFold(strings.TrimSpace(header.Key), "Cookie") {
+			merged = append(merged, header)
+			continue
+		}
+		value := strings.TrimSpace(strings.Trim(strings.TrimSpace(header.Value), ";"))
+		if cookie == nil {
+			cookie = &Header{Key: header.Key, Value: value, Description: header.Description}
+			merged = append(merged, cookie)
+			continue
+		}
+		switch {
+		case value == "":
+		case cookie.Value == "":
+			cookie.Value = value
+		default:
+			cookie.Value += "; " + value
+		}
+	}
+	return merged
+}
+
+// withDefaultHeader appends the header unless the request already declares one
+// with the same key. The headers of the request, repeated keys included, stay
+// as they are and in their order.
+func withDefaultHeader(headers []*Header, key, value string) []*Header {
+	if findHeader(headers, key) != nil {
+		return headers
+	}
+	// Never write into spare capacity another slice of the headers may share
+	return append(headers[:len(headers):len(headers)], &Header{Key: key, Value: value})
+}
@@ -0,0 +1,153 @@
+package converter
+
+import "testing"
+
+func TestConvertRequestURLForms(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "string",
+			url:  `"https://api.example.com/users?page=1"`,
+			want: "# @name get\nGET https://api.example.com/users?page=1\n",
+		},
+		{
+			name: "object",
+			url:  `{"raw": "https://api.example.com/users?page=1", "protocol": "https", "host": ["api", "example", "com"], "path": ["users"], "query": [{"key": "page", "value": "1"}]}`,
+			want: "# @name get\nGET https://api.example.com/users?page=1\n",
+		},
+		{
+			name: "object without raw",
+			url:  `{"protocol": "https", "host": ["api", "example", "com"], "path": ["users", ":id"], "variable": [{"key": "id", "value": "7"}]}`,
+			want: "# @name get\nGET https://api.example.com/users/7\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &Item{Name: "Get", Request: &Request{Method: "GET", URL: []byte(tt.url)}}
+			got, err := ConvertRequest(item, nil, nil)
+			if err != nil {
+				t.Fatalf("ConvertRequest() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertRequestRawBodyTrailingNewline(t *testing.T) {
+	post := &Item{Name: "Post", Request: &Request{Method: "POST", URL: []byte(`"https://api.example.com"`), Body: []byte(`{"mode": "raw", "raw": "  hello\nworld\n"}`)}}
+	got, err := ConvertRequest(post, nil, nil)
+	if err != nil {
+		t.Fatalf("ConvertRequest() error = %v", err)
+	}
+	// The line break the body ends with stays as its empty last line
+	want := "# @name post\nPOST https://api.example.com\n\n  hello\nworld\n\n"
+	if got != want {
+		t.Errorf("ConvertRequest() = %q, want %q", got, want)
+	}
+
+	get := &Item{Name: "Get", Request: &Request{Method: "GET", URL: []byte(`"https://api.example.com"`)}}
+	next, err := ConvertRequest(get, nil, nil)
+	if err != nil {
+		t.Fatalf("ConvertRequest() error = %v", err)
+	}
+	want += "###\n\n# @name get\nGET https://api.example.com\n"
+	if joined := JoinRequests([]string{got, next}); joined != want {
+		t.Errorf("JoinRequests() = %q, want %q", joined, want)
+	}
+}
+
+func TestConvertRequestHeaderOrder(t *testing.T) {
+	data := []byte(`{
+		"info": {"name": "headers", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+		"item": [{"name": "Folder", "item": [{
+			"name": "Create",
+			"request": {
+				"method": "POST",
+				"url": "{{baseUrl}}/users",
+				"header": [{"key": "X-Zeta", "value": "z"}, {"key": "Cookie", "value": "a=1"}, {"key": "X-Alpha", "value": "a"}, {"key": "Cookie", "value": "b=2"}],
+				"body": {"mode": "raw", "raw": "{\"name\": \"x\"}", "options": {"raw": {"language": "json"}}}
+			}
+		}], "header": [{"key": "X-Folder", "value": "f"}]}]
+	}`)
+
+	// The folder's and the request's headers in their order, then the auth
+	// and body ones
+	want := "# @name create\nPOST {{baseUrl}}/users\nX-Folder: f\nX-Zeta: z\nCookie: a=1; b=2\nX-Alpha: a\nAuthorization: Bearer {{token}}\nContent-Type: application/json\n\n{\n  \"name\": \"x\"\n}\n"
+	for i := 0; i < 20; i++ {
+		converted, err := ConvertCollection(data, nil)
+		if err != nil {
+			t.Fatalf("ConvertCollection() error = %v", err)
+		}
+		if len(converted) != 1 {
+			t.Fatalf("got %d requests, want 1", len(converted))
+		}
+		if converted[0].Content != want {
+			t.Fatalf("run %d: ConvertCollection() = %q, want %q", i+1, converted[0].Content, want)
+		}
+	}
+}
+
+func TestConvertRequestKeepsAccept(t *testing.T) {
+	item := &Item{Name: "Create", Request: &Request{
+		Method: "POST",
+		URL:    []byte(`"https://api.example.com/users"`),
+		Header: Headers{{Key: "accept", Value: "application/xml"}},
+		Body:   []byte(`{"mode": "raw", "raw": "<user/>", "options": {"raw": {"language": "xml"}}}`),
+	}}
+	ctx := &RequestContext{Auth: &Auth{Type: "bearer", Bearer: AuthAttributes{{Key: "token", Value: "{{token}}"}}}}
+
+	tests := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{
+			name: "as written",
+			opts: &Options{},
+			want: "accept: application/xml\nAuthorization: Bearer {{token}}\nContent-Type: application/xml\n",
+		},
+		{
+			name: "normalized",
+			opts: &Options{NormalizeHeaders: true},
+			want: "Accept: application/xml\nAuthorization: Bearer {{token}}\nContent-Type: application/xml\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertRequest(item, ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("ConvertRequest() error = %v", err)
+			}
+			want := "# @name create\nPOST https://api.example.com/users\n" + tt.want + "\n<user/>\n"
+			if got != want {
+				t.Errorf("ConvertRequest() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestGuardRequestSeparators(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "no separator", body: "a\n# b\n", want: "a\n# b\n"},
+		{name: "line start", body: "a\n### b\n", want: "a\n{{\"###\"}} b\n"},
+		{name: "indented", body: "a\n  ### b\n\t###\n", want: "a\n  {{\"###\"}} b\n\t{{\"###\"}}\n"},
+		{name: "inside a line", body: "a ### b\n", want: "a ### b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := guardRequestSeparators(tt.body); got != tt.want {
+				t.Errorf("guardRequestSeparators() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
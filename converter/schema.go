@@ -0,0 +1,283 @@
+package converter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema versions of the Postman collection format the converter reads.
+const (
+	schemaV20 = "v2.0.0"
+	schemaV21 = "v2.1.0"
+)
+
+// SchemaVersion returns the version part of the schema URL, e.g. "v2.1.0"
+// for https://schema.getpostman.com/json/collection/v2.1.0/collection.json.
+func (i *Info) SchemaVersion() string {
+	for _, part := range strings.Split(i.Schema, "/") {
+		if strings.HasPrefix(part, "v") && strings.Contains(part, ".") {
+			return part
+		}
+	}
+	return ""
+}
+
+// ParseCollection parses a Postman collection, accepting both the v2.0 and
+// the v2.1 schema. Exports without a schema are read as v2.1. Errors are of
+// type *ParseError.
+func ParseCollection(data []byte) (*PostmanCollection, error) {
+	collection, err := parseCollection(data)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	return collection, nil
+}
+
+func parseCollection(data []byte) (*PostmanCollection, error) {
+	if err := validateCollection(data); err != nil {
+		return nil, err
+	}
+
+	var collection PostmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, err
+	}
+
+	collection.Items = orderItems(collection.Items, collection.Order, collection.FoldersOrder)
+
+	switch version := collection.Info.SchemaVersion(); version {
+	case schemaV21, "":
+		return &collection, nil
+	case schemaV20:
+		if err := upgradeV20Items(collection.Items); err != nil {
+			return nil, err
+		}
+		return &collection, nil
+	default:
+		return nil, fmt.Errorf("unsupported collection schema %s, export the collection as v2.1", version)
+	}
+}
+
+// WorkspaceCollections returns the collections embedded in an export
+// wrapping them, like the data dump of a Postman workspace listing them under
+// "collections" or the Postman API response holding one under "collection".
+// Bare collections and anything else give nil.
+func WorkspaceCollections(data []byte) []json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields["item"] != nil {
+		return nil
+	}
+
+	if collection, ok := fields["collection"]; ok {
+		return []json.RawMessage{collection}
+	}
+	var collections []json.RawMessage
+	if err := json.Unmarshal(fields["collections"], &collections); err != nil {
+		return nil
+	}
+	return collections
+}
+
+// validateCollection checks that data has the shape of a Postman collection,
+// so that other JSON files are reported rather than read as empty
+// collections.
+func validateCollection(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return err
+		}
+		return errors.New("not a Postman collection: expected a JSON object")
+	}
+
+	if _, ok := fields["requests"]; ok && fields["item"] == nil {
+		return errors.New("not a Postman v2 collection: this looks like the v1 format, export the collection as v2.1")
+	}
+	if _, ok := fields["values"]; ok && fields["item"] == nil {
+		return errors.New("not a Postman collection: this looks like an environment")
+	}
+
+	var info map[string]json.RawMessage
+	if err := json.Unmarshal(fields["info"], &info); err != nil || info == nil {
+		return errors.New("not a Postman collection: missing the info object")
+	}
+	// Collections without requests may leave the item list out
+	var items []json.RawMessage
+	if err := json.Unmarshal(fields["item"], &items); err != nil && fields["item"] != nil {
+		return errors.New("not a Postman collection: the item field is not a list")
+	}
+	return nil
+}
+
+// upgradeV20Items rewrites the plain string URLs of v2.0 requests into the
+// URL object form used by v2.1.
+func upgradeV20Items(items []*Item) error {
+	for _, item := range items {
+		if item.Request != nil {
+			var rawURL string
+			if err := json.Unmarshal(item.Request.URL, &rawURL); err == nil {
+				urlData, err := json.Marshal(&URL{Raw: rawURL})
+				if err != nil {
+					return err
+				}
+				item.Request.URL = urlData
+			}
+		}
+
+		if err := upgradeV20Items(item.Items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderItems sorts items, and the items of their folders, the way the order
+// and folders_order fields list them: folders first, as Postman shows them,
+// then requests. Items not listed keep their position after the listed ones.
+func orderItems(items []*Item, order, foldersOrder []string) []*Item {
+	for _, item := range items {
+		item.Items = orderItems(item.Items, item.Order, item.FoldersOrder)
+	}
+	if len(order) == 0 && len(foldersOrder) == 0 {
+		return items
+	}
+
+	rank := map[string]int{}
+	for i, id := range foldersOrder {
+		rank[id] = i
+	}
+	for i, id := range order {
+		rank[id] = len(foldersOrder) + i
+	}
+	itemRank := func(item *Item) int {
+		for _, id := range []string{item.ID, item.PostmanID} {
+			if r, ok := rank[id]; ok && id != "" {
+				return r
+			}
+		}
+		return len(rank)
+	}
+
+	ordered := append([]*Item(nil), items...)
+	sort.SliceStable(ordered, func(i, j int) bool { return itemRank(ordered[i]) < itemRank(ordered[j]) })
+	return ordered
+}
+
+// Headers -
+type Headers []*Header
+
+// UnmarshalJSON accepts the header list as well as the "Key: Value" lines
+// v2.0 exports may store headers as.
+func (h *Headers) UnmarshalJSON(data []byte) error {
+	var lines string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		var headers []*Header
+		if err := json.Unmarshal(data, &headers); err != nil {
+			return err
+		}
+		*h = headers
+		return nil
+	}
+
+	*h = nil
+	for _, line := range strings.Split(lines, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(key) == "" {
+			continue
+		}
+		*h = append(*h, &Header{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return nil
+}
+
+// URLSegments -
+type URLSegments []string
+
+// UnmarshalJSON accepts host and path either as a single string or as a
+// list of segments, where path segments may also be {"type", "value"}
+// objects.
+func (u *URLSegments) UnmarshalJSON(data []byte) error {
+	var joined string
+	if err := json.Unmarshal(data, &joined); err == nil {
+		*u = URLSegments{joined}
+		return nil
+	}
+
+	var segments []json.RawMessage
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return err
+	}
+
+	*u = nil
+	for _, segmentData := range segments {
+		var segment string
+		if err := json.Unmarshal(segmentData, &segment); err != nil {
+			var object struct {
+				Value string `json:"value"`
+			}
+			if err := json.Unmarshal(segmentData, &object); err != nil {
+				return err
+			}
+			segment = object.Value
+		}
+		*u = append(*u, segment)
+	}
+	return nil
+}
+
+// AuthAttributes -
+type AuthAttributes []*AuthAttribute
+
+// UnmarshalJSON accepts the v2.1 list of key/value attributes as well as the
+// v2.0 object mapping attribute keys to values.
+func (a *AuthAttributes) UnmarshalJSON(data []byte) error {
+	var attributes []*AuthAttribute
+	if err := json.Unmarshal(data, &attributes); err == nil {
+		*a = attributes
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	*a = nil
+	for _, key := range keys {
+		*a = append(*a, &AuthAttribute{Key: key, Value: values[key]})
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts a request given as a plain URL string, which both
+// schema versions allow as a shorthand for a GET request.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	var rawURL string
+	if err := json.Unmarshal(data, &rawURL); err == nil {
+		urlData, err := json.Marshal(&URL{Raw: rawURL})
+		if err != nil {
+			return err
+		}
+		*r = Request{Method: "GET", URL: urlData}
+		return nil
+	}
+
+	type request Request
+	if err := json.Unmarshal(data, (*request)(r)); err != nil {
+		return err
+	}
+	if r.Method == "" {
+		r.Method = "GET"
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ScriptLines -
+type ScriptLines []string
+
+// UnmarshalJSON accepts the list of source lines as well as the whole
+// source as a single string.
+func (l *ScriptLines) UnmarshalJSON(data []byte) error {
+	var source string
+	if err := json.Unmarshal(data, &source); err == nil {
+		*l = strings.Split(source, "\n")
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*l = lines
+	return nil
+}
+
+// eventScript returns the source of the scripts listening to the given
+// event, e.g. "test" or "prerequest".
+func eventScript(events []*Event, listen string) string {
+	var sources []string
+	for _, event := range events {
+		if event.Listen != listen || event.Script == nil {
+			continue
+		}
+		if source := strings.TrimSpace(strings.Join(event.Script.Exec, "\n")); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return strings.Join(sources, "\n")
+}
+
+var (
+	// e.g. var jsonData = pm.response.json();
+	responseAliasPattern = regexp.MustCompile(`^(?:var|let|const)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:pm\.response\.json\(\)|JSON\.parse\(\s*(?:responseBody|pm\.response\.text\(\))\s*\))$`)
+	// e.g. pm.environment.set("token", jsonData.access_token);
+	setVariablePattern = regexp.MustCompile(`^(?:pm\.(?:environment|collectionVariables|globals|variables)\.set|postman\.set(?:Environment|Global)Variable)\(\s*["']([^"']+)["']\s*,\s*(.+?)\s*\)$`)
+	// e.g. pm.collectionVariables.set('page', page + 1) anywhere in a script
+	scriptSetVariablePattern = regexp.MustCompile(`(?:pm\.(?:environment|collectionVariables|globals|variables)\.set|postman\.set(?:Environment|Global)Variable)\(\s*["'\x60]([^"'\x60]+)["'\x60]`)
+	// e.g. .data.items[0]["id"]
+	jsonPathPattern   = regexp.MustCompile(`^(?:\.[A-Za-z_$][\w$]*|\[\d+\]|\[["'][^"']*["']\])*$`)
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+)
+
+// scriptVariables returns the variables the scripts of events set, whether
+// or not their values can be translated.
+func scriptVariables(events []*Event) []string {
+	var variables []string
+	for _, listen := range []string{"prerequest", "test"} {
+		for _, match := range scriptSetVariablePattern.FindAllStringSubmatch(eventScript(events, listen), -1) {
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// capture -
+type capture struct {
+	Variable string
+	// Expression reading the value off the httpYac response
+	Expression string
+}
+
+// testScriptTranslation -
+type testScriptTranslation struct {
+	Captures []*capture
+	// httpYac assertions, without their leading "??"
+	Assertions []string
+	// Source of the script when parts of it could not be translated
+	Unconverted string
+}
+
+// translateTestScript picks up the statements of a Postman test script that
+// store a value of the JSON response in a variable or assert on the
+// response, within pm.test blocks or not. Any other statement makes the
+// translation partial, keeping the whole script for manual review.
+func translateTestScript(source string) *testScriptTranslation {
+	translation := &testScriptTranslation{}
+	aliases := map[string]bool{}
+
+	for _, line := range strings.Split(source, "\n") {
+		statement := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ";"))
+		if statement == "" || strings.HasPrefix(statement, "//") {
+			continue
+		}
+
+		if match := responseAliasPattern.FindStringSubmatch(statement); match != nil {
+			aliases[match[1]] = true
+			continue
+		}
+
+		if match := setVariablePattern.FindStringSubmatch(statement); match != nil {
+			if expression, ok := responseExpression(match[2], aliases); ok {
+				translation.Captures = append(translation.Captures, &capture{Variable: match[1], Expression: expression})
+				continue
+			}
+		}
+
+		// httpYac assertions need no grouping, only what pm.test checks matters
+		if testOpenPattern.MatchString(statement) || testClosePattern.MatchString(statement) {
+			continue
+		}
+		if assertion, ok := translateAssertion(statement, aliases); ok {
+			translation.Assertions = append(translation.Assertions, assertion)
+			continue
+		}
+
+		translation.Unconverted = source
+	}
+	return translation
+}
+
+// responseExpression translates a Postman expression reading the JSON
+// response, directly or through an alias, into its httpYac counterpart.
+func responseExpression(expression string, aliases map[string]bool) (string, bool) {
+	root, path := expression, ""
+	if strings.HasPrefix(expression, "pm.response.json()") {
+		root, path = "pm.response.json()", strings.TrimPrefix(expression, "pm.response.json()")
+	} else if i := strings.IndexAny(expression, ".["); i >= 0 {
+		root, path = expression[:i], expression[i:]
+	}
+
+	if root != "pm.response.json()" && !aliases[root] {
+		return "", false
+	}
+	if !jsonPathPattern.MatchString(path) {
+		return "", false
+	}
+	return "response.parsedBody" + path, true
+}
+
+// renderTestScript writes the captures as an httpYac script running after
+// the response arrived, followed by whatever could not be translated as
+// commented out source.
+func renderTestScript(translation *testScriptTranslation) string {
+	if len(translation.Captures) == 0 && translation.Unconverted == "" {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("{{\n")
+	for _, capture := range translation.Captures {
+		target := "exports." + capture.Variable
+		if !identifierPattern.MatchString(capture.Variable) {
+			target = fmt.Sprintf("exports[%q]", capture.Variable)
+		}
+		sb.WriteString(fmt.Sprintf("  %s = %s;\n", target, capture.Expression))
+	}
+	if translation.Unconverted != "" {
+		writeCommentedScript(&sb, "Postman test script, review and port manually:", translation.Unconverted)
+	}
+	sb.WriteString("}}")
+	return sb.String()
+}
+
+// renderAssertions writes the assertions as httpYac ?? lines.
+func renderAssertions(assertions []string) string {
+	sb := strings.Builder{}
+	for _, assertion := range assertions {
+		sb.WriteString(fmt.Sprintf("?? %s\n", assertion))
+	}
+	return sb.String()
+}
+
+// renderPreRequestScript writes the Postman pre-request script into an
+// httpYac script block, which runs before the request is sent.
+func renderPreRequestScript(source string) string {
+	sb := strings.Builder{}
+	sb.WriteString("{{\n")
+	writeCommentedScript(&sb, "Postman pre-request script, review and port manually:", source)
+	sb.WriteString("}}")
+	return sb.String()
+}
+
+// writeCommentedScript writes the script source as JavaScript line comments
+// for an httpYac script block.
+func writeCommentedScript(sb *strings.Builder, title, source string) {
+	sb.WriteString("  // " + title + "\n")
+	for _, line := range strings.Split(source, "\n") {
+		sb.WriteString(strings.TrimRight("  // "+line, " ") + "\n")
+	}
+}
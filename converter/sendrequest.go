@@ -0,0 +1,202 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// e.g. "https://auth.example.com/token" or 'POST'
+	stringLiteralPattern = regexp.MustCompile(`^(?:"([^"\\]*)"|'([^'\\]*)')$`)
+	// e.g. function (err, res) { ... } or (err, res) => ...
+	sendRequestCallbackPattern = regexp.MustCompile(`(?s)^(?:function\s*)?\(\s*[A-Za-z_$][\w$]*\s*,\s*([A-Za-z_$][\w$]*)\s*\)\s*(?:=>)?\s*(.*)$`)
+)
+
+// sentRequest - a request a pre-request script sends with pm.sendRequest
+type sentRequest struct {
+	Method string
+	URL    string
+	// What the callback does with the response
+	Translation *testScriptTranslation
+	// Source of the pre-request script without the pm.sendRequest call
+	Rest string
+}
+
+// translateSendRequest picks up the single pm.sendRequest call of a
+// pre-request script, sent to a URL given as a string or as the url and
+// method of a request object, whose callback only captures values of the
+// response or asserts on it. Anything more complex is not translated.
+func translateSendRequest(source string) (*sentRequest, bool) {
+	const call = "pm.sendRequest("
+	start := strings.Index(source, call)
+	if start < 0 || strings.Count(source, call) > 1 {
+		return nil, false
+	}
+	end, ok := closingParen(source, start+len(call)-1)
+	if !ok {
+		return nil, false
+	}
+	args := splitTopLevel(source[start+len(call):end], ',')
+	if len(args) == 0 || len(args) > 2 {
+		return nil, false
+	}
+
+	sent := &sentRequest{Method: "GET", Translation: &testScriptTranslation{}}
+	if url, ok := stringLiteral(args[0]); ok {
+		sent.URL = url
+	} else if !sent.readRequestObject(args[0]) {
+		return nil, false
+	}
+
+	// Read the response the callback uses as the one of the sent request
+	if len(args) == 2 {
+		match := sendRequestCallbackPattern.FindStringSubmatch(strings.TrimSpace(args[1]))
+		if match == nil {
+			return nil, false
+		}
+		body := strings.TrimSpace(match[2])
+		if strings.HasPrefix(body, "{") && strings.HasSuffix(body, "}") {
+			body = body[1 : len(body)-1]
+		}
+		response := regexp.MustCompile(`\b` + regexp.QuoteMeta(match[1]) + `\.(json|text)\(\)|\b` + regexp.QuoteMeta(match[1]) + `\.code\b`)
+		body = response.ReplaceAllStringFunc(body, func(s string) string {
+			return "pm.response" + s[len(match[1]):]
+		})
+		sent.Translation = translateTestScript(body)
+		if sent.Translation.Unconverted != "" {
+			return nil, false
+		}
+	}
+
+	if end+1 < len(source) && source[end+1] == ';' {
+		end++
+	}
+	sent.Rest = strings.TrimSpace(source[:start] + source[end+1:])
+	return sent, true
+}
+
+// readRequestObject reads the url and method of a request object literal,
+// leaving anything else, like headers or a body, to manual porting.
+func (s *sentRequest) readRequestObject(object string) bool {
+	object = strings.TrimSpace(object)
+	if !strings.HasPrefix(object, "{") || !strings.HasSuffix(object, "}") {
+		return false
+	}
+	for _, field := range splitTopLevel(object[1:len(object)-1], ',') {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			return false
+		}
+		literal, ok := stringLiteral(value)
+		if !ok {
+			return false
+		}
+		switch strings.Trim(strings.TrimSpace(key), `"'`) {
+		case "url":
+			s.URL = literal
+		case "method":
+			s.Method = strings.ToUpper(literal)
+		default:
+			return false
+		}
+	}
+	return s.URL != ""
+}
+
+// stringLiteral returns the value of a JavaScript string literal without
+// escapes.
+func stringLiteral(s string) (string, bool) {
+	match := stringLiteralPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return "", false
+	}
+	return match[1] + match[2], true
+}
+
+// closingParen returns the position of the parenthesis closing the one at
+// open, skipping over string literals.
+func closingParen(source string, open int) (int, bool) {
+	depth := 0
+	var quote byte
+	for i := open; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// splitTopLevel splits source at the separators outside of brackets and
+// string literals.
+func splitTopLevel(source string, separator byte) []string {
+	var parts []string
+	depth, last := 0, 0
+	var quote byte
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == separator && depth == 0:
+			parts = append(parts, source[last:i])
+			last = i + 1
+		}
+	}
+	if strings.TrimSpace(source[last:]) != "" {
+		parts = append(parts, source[last:])
+	}
+	return parts
+}
+
+// sentRequestName names the request sent before the one named name.
+func sentRequestName(name string) string {
+	return name + "_send_request"
+}
+
+// renderSentRequest writes the request a pre-request script sends as an
+// httpYac request of its own, with the captures and assertions of its
+// callback.
+func renderSentRequest(name string, sent *sentRequest, rewrite func(string) string) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("# @name %s\n", name))
+	sb.WriteString(fmt.Sprintf("%s %s\n", sent.Method, rewrite(sent.URL)))
+	if len(sent.Translation.Assertions) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(renderAssertions(sent.Translation.Assertions))
+	}
+	if script := renderTestScript(sent.Translation); script != "" {
+		sb.WriteString("\n")
+		sb.WriteString(script)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
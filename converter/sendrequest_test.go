@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+const tokenPreRequestScript = `pm.sendRequest("{{authUrl}}/token", function (err, res) {
+    pm.environment.set("token", res.json().access_token);
+});
+console.log("sent");`
+
+func TestTranslateSendRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		ok     bool
+		method string
+		url    string
+		rest   string
+	}{
+		{
+			name:   "string url",
+			source: tokenPreRequestScript,
+			ok:     true,
+			method: "GET",
+			url:    "{{authUrl}}/token",
+			rest:   `console.log("sent");`,
+		},
+		{
+			name:   "request object",
+			source: `pm.sendRequest({url: "https://auth.example.com/token", method: "post"});`,
+			ok:     true,
+			method: "POST",
+			url:    "https://auth.example.com/token",
+		},
+		{
+			name:   "request object with a body",
+			source: `pm.sendRequest({url: "https://auth.example.com/token", body: "x"});`,
+		},
+		{
+			name:   "two calls",
+			source: `pm.sendRequest("https://a.example.com"); pm.sendRequest("https://b.example.com");`,
+		},
+		{
+			name:   "no call",
+			source: `pm.environment.set("a", "1");`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, ok := translateSendRequest(tt.source)
+			if ok != tt.ok {
+				t.Fatalf("translateSendRequest() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if sent.Method != tt.method || sent.URL != tt.url || sent.Rest != tt.rest {
+				t.Errorf("translateSendRequest() = %s %s, rest %q, want %s %s, rest %q", sent.Method, sent.URL, sent.Rest, tt.method, tt.url, tt.rest)
+			}
+		})
+	}
+}
+
+func TestConvertRequestSentRequestRegions(t *testing.T) {
+	item := &Item{
+		Name:    "Get Users",
+		Request: &Request{Method: "GET", URL: []byte(`"{{baseUrl}}/users"`)},
+		Events:  []*Event{{Listen: "prerequest", Script: &Script{Exec: ScriptLines{tokenPreRequestScript}}}},
+	}
+	ctx := &RequestContext{Imports: []string{"./_collection.http"}, Refs: []string{"login"}}
+	got, err := ConvertRequest(item, ctx, nil)
+	if err != nil {
+		t.Fatalf("ConvertRequest() error = %v", err)
+	}
+
+	// The sent request comes first in a region of its own, the imports
+	// belong to the region of the request they are imported for
+	want := "# @name get_users_send_request\n" +
+		"GET {{authUrl}}/token\n" +
+		"\n" +
+		"{{\n  exports.token = response.parsedBody.access_token;\n}}\n" +
+		"###\n\n" +
+		"# @import ./_collection.http\n" +
+		"# @name get_users\n" +
+		"# @ref get_users_send_request\n" +
+		"# @ref login\n"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("ConvertRequest() = %q, want it to start with %q", got, want)
+	}
+}
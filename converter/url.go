@@ -0,0 +1,159 @@
+package converter
+
+import (
+	"net/url"
+	"strings"
+)
+
+// buildRequestURL returns the URL for the request line, rebuilt from its
+// parts when the raw URL is missing. When Postman has the
+// query parameters in structured form they take precedence over whatever
+// query string the raw URL carries, as raw may be empty or stale. The extra
+// parameters are appended after the request's own ones.
+func buildRequestURL(u *URL, extraQuery []*QueryParam) string {
+	base, fragment := u.Raw, ""
+	if strings.TrimSpace(base) == "" {
+		base = joinURL(u)
+	}
+	if i := strings.Index(base, "#"); i >= 0 {
+		base, fragment = base[:i], base[i:]
+	}
+
+	query := ""
+	if i := strings.Index(base, "?"); i >= 0 {
+		base, query = base[:i], base[i+1:]
+	}
+	base = substitutePathVariables(base, u.Variable)
+	if len(u.Query) > 0 && !queryMatches(query, u.Query) {
+		query = encodeQuery(u.Query)
+	}
+	if extra := encodeQuery(extraQuery); extra != "" {
+		if query != "" {
+			query += "&"
+		}
+		query += extra
+	}
+
+	if query != "" {
+		base += "?" + query
+	}
+	return base + fragment
+}
+
+// substitutePathVariables replaces :name path segments bound in the URL's
+// variables with their value, or with a {{name}} reference when no value is
+// given, since httpYac would send :name literally.
+func substitutePathVariables(rawURL string, variables []*PathVariable) string {
+	if len(variables) == 0 {
+		return rawURL
+	}
+
+	segments := strings.Split(rawURL, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		for _, variable := range variables {
+			if segment != ":"+variable.Key {
+				continue
+			}
+			if variable.Value == "" {
+				segments[i] = "{{" + variable.Key + "}}"
+			} else {
+				segments[i] = escapePreservingVariables(string(variable.Value), url.PathEscape)
+			}
+			break
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// joinURL rebuilds a URL without query string from the structured parts
+// Postman keeps next to the raw URL.
+func joinURL(u *URL) string {
+	sb := strings.Builder{}
+	if u.Protocol != "" {
+		sb.WriteString(u.Protocol + "://")
+	}
+	sb.WriteString(joinHost(u.Host))
+	if u.Port != "" {
+		sb.WriteString(":" + u.Port)
+	}
+	// Segments are joined verbatim, so :pathVariable segments stay intact
+	if path := strings.TrimPrefix(strings.Join(u.Path, "/"), "/"); path != "" {
+		sb.WriteString("/" + path)
+	}
+	return sb.String()
+}
+
+// RequestHost returns the host the request is sent to as written in
+// Postman, e.g. "api.example.com" or "{{baseUrl}}".
+func RequestHost(request *Request) string {
+	url := parseURL(request.URL)
+	if host := joinHost(url.Host); host != "" {
+		return host
+	}
+	host := url.Raw
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+len("://"):]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// joinHost joins the host segments Postman split the hostname into at its
+// dots. A host that is a lone variable placeholder, like {{baseUrl}}, usually
+// holds a whole base URL and is used as is.
+func joinHost(host []string) string {
+	if len(host) == 1 {
+		return host[0]
+	}
+	return strings.Join(host, ".")
+}
+
+// queryEscaper escapes the characters that would end a query parameter,
+// leaving the rest as typed in Postman, which may already be percent-encoded.
+var queryEscaper = strings.NewReplacer(" ", "%20", "&", "%26", "#", "%23")
+
+// queryKeyEscaper also escapes the = that would end a query parameter key.
+var queryKeyEscaper = strings.NewReplacer(" ", "%20", "&", "%26", "#", "%23", "=", "%3D")
+
+// encodeQuery joins the enabled params into a query string. Keys and values
+// are written as Postman stores them, only what would break the query
+// string apart is escaped.
+func encodeQuery(params []*QueryParam) string {
+	var pairs []string
+	for _, param := range params {
+		if param.Disabled {
+			continue
+		}
+		pairs = append(pairs, escapePreservingVariables(param.Key, queryKeyEscaper.Replace)+"="+escapePreservingVariables(param.Value, queryEscaper.Replace))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// queryMatches reports whether the query string of the raw URL holds
+// exactly the enabled params, in which case it is used as is.
+func queryMatches(query string, params []*QueryParam) bool {
+	var pairs []string
+	if query != "" {
+		pairs = strings.Split(query, "&")
+	}
+	i := 0
+	for _, param := range params {
+		if param.Disabled {
+			continue
+		}
+		if i >= len(pairs) {
+			return false
+		}
+		key, value, _ := strings.Cut(pairs[i], "=")
+		if key != param.Key || value != param.Value {
+			return false
+		}
+		i++
+	}
+	return i == len(pairs)
+}
@@ -0,0 +1,49 @@
+package converter
+
+import "testing"
+
+func TestBuildRequestURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  URL
+		want string
+	}{
+		{
+			name: "pre-encoded value",
+			url:  URL{Raw: "https://api.example.com/search?q=hello%20world", Query: []*QueryParam{{Key: "q", Value: "hello%20world"}}},
+			want: "https://api.example.com/search?q=hello%20world",
+		},
+		{
+			name: "pre-encoded value with stale raw",
+			url:  URL{Raw: "https://api.example.com/search", Query: []*QueryParam{{Key: "q", Value: "hello%20world"}}},
+			want: "https://api.example.com/search?q=hello%20world",
+		},
+		{
+			name: "reserved characters kept",
+			url:  URL{Raw: "https://api.example.com/search", Query: []*QueryParam{{Key: "redirect", Value: "https://x.example.com/a,b"}, {Key: "page", Value: "{{page}}"}}},
+			want: "https://api.example.com/search?redirect=https://x.example.com/a,b&page={{page}}",
+		},
+		{
+			name: "separators escaped",
+			url:  URL{Raw: "https://api.example.com/search", Query: []*QueryParam{{Key: "a=b", Value: "x & y#z"}}},
+			want: "https://api.example.com/search?a%3Db=x%20%26%20y%23z",
+		},
+		{
+			name: "raw agreeing with the params",
+			url:  URL{Raw: "{{baseUrl}}/users?sort=name:asc&page=1#top", Query: []*QueryParam{{Key: "sort", Value: "name:asc"}, {Key: "page", Value: "1"}}},
+			want: "{{baseUrl}}/users?sort=name:asc&page=1#top",
+		},
+		{
+			name: "disabled params left out",
+			url:  URL{Raw: "{{baseUrl}}/users?page=1&debug=true", Query: []*QueryParam{{Key: "page", Value: "1"}, {Key: "debug", Value: "true", Disabled: true}}},
+			want: "{{baseUrl}}/users?page=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildRequestURL(&tt.url, nil); got != tt.want {
+				t.Errorf("buildRequestURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	variablePattern        = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+	dynamicVariablePattern = regexp.MustCompile(`\{\{(\$[A-Za-z]+)\}\}`)
+)
+
+// dynamicVariables maps Postman dynamic variables to the httpYac variables
+// producing the same kind of value.
+var dynamicVariables = map[string]string{
+	"$guid":         "{{$guid}}",
+	"$randomUUID":   "{{$guid}}",
+	"$timestamp":    "{{$timestamp}}",
+	"$isoTimestamp": "{{$datetime iso8601}}",
+	"$randomInt":    "{{$randomInt 0 1000}}",
+}
+
+// fakerVariables maps Postman's faker dynamic variables to the faker.js calls
+// generating the same kind of value. Postman bundles faker.js, httpYac
+// scripts require it from the node_modules of the project.
+var fakerVariables = map[string]string{
+	"$randomFirstName":      "person.firstName()",
+	"$randomLastName":       "person.lastName()",
+	"$randomFullName":       "person.fullName()",
+	"$randomNamePrefix":     "person.prefix()",
+	"$randomNameSuffix":     "person.suffix()",
+	"$randomJobTitle":       "person.jobTitle()",
+	"$randomUserName":       "internet.userName()",
+	"$randomEmail":          "internet.email()",
+	"$randomExampleEmail":   "internet.exampleEmail()",
+	"$randomPassword":       "internet.password()",
+	"$randomUrl":            "internet.url()",
+	"$randomDomainName":     "internet.domainName()",
+	"$randomIP":             "internet.ip()",
+	"$randomIPV6":           "internet.ipv6()",
+	"$randomMACAddress":     "internet.mac()",
+	"$randomUserAgent":      "internet.userAgent()",
+	"$randomPhoneNumber":    "phone.number()",
+	"$randomCity":           "location.city()",
+	"$randomStreetName":     "location.street()",
+	"$randomStreetAddress":  "location.streetAddress()",
+	"$randomCountry":        "location.country()",
+	"$randomCountryCode":    "location.countryCode()",
+	"$randomLatitude":       "location.latitude()",
+	"$randomLongitude":      "location.longitude()",
+	"$randomCompanyName":    "company.name()",
+	"$randomCatchPhrase":    "company.catchPhrase()",
+	"$randomProductName":    "commerce.productName()",
+	"$randomPrice":          "commerce.price()",
+	"$randomColor":          "color.human()",
+	"$randomHexColor":       "color.rgb()",
+	"$randomWord":           "lorem.word()",
+	"$randomWords":          "lorem.words()",
+	"$randomLoremSentence":  "lorem.sentence()",
+	"$randomLoremParagraph": "lorem.paragraph()",
+	"$randomLoremText":      "lorem.text()",
+	"$randomAlphaNumeric":   "string.alphanumeric()",
+	"$randomBoolean":        "datatype.boolean()",
+	"$randomDateFuture":     "date.future().toISOString()",
+	"$randomDatePast":       "date.past().toISOString()",
+	"$randomDateRecent":     "date.recent().toISOString()",
+	"$randomMonth":          "date.month()",
+	"$randomWeekday":        "date.weekday()",
+	"$randomFileName":       "system.fileName()",
+	"$randomMimeType":       "system.mimeType()",
+	"$randomBankAccount":    "finance.accountNumber()",
+	"$randomCurrencyCode":   "finance.currencyCode()",
+	"$randomAvatarImage":    "image.avatar()",
+	"$randomImageUrl":       "image.url()",
+}
+
+// fakerScript returns the httpYac script expression calling faker.js.
+func fakerScript(call string) string {
+	return "{{ require('@faker-js/faker').faker." + call + " }}"
+}
+
+// dynamicVariableTranslator rewrites Postman dynamic variables and remembers
+// the ones it has no translation for, so they can be reported once per
+// request.
+type dynamicVariableTranslator struct {
+	unknown []string
+}
+
+func (t *dynamicVariableTranslator) translate(s string) string {
+	return dynamicVariablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := dynamicVariablePattern.FindStringSubmatch(match)[1]
+		if replacement, ok := dynamicVariables[name]; ok {
+			return replacement
+		}
+		if call, ok := fakerVariables[name]; ok {
+			return fakerScript(call)
+		}
+
+		for _, unknown := range t.unknown {
+			if unknown == name {
+				return match
+			}
+		}
+		t.unknown = append(t.unknown, name)
+		return match
+	})
+}
+
+// escapePreservingVariables applies escape to everything in s except
+// {{variable}} references, which httpYac has to see verbatim.
+func escapePreservingVariables(s string, escape func(string) string) string {
+	sb := strings.Builder{}
+	last := 0
+	for _, loc := range variablePattern.FindAllStringIndex(s, -1) {
+		sb.WriteString(escape(s[last:loc[0]]))
+		sb.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(escape(s[last:]))
+	return sb.String()
+}
+
+// Scopes variable defaults are collected from, by increasing precedence.
+const (
+	scopeURL = iota
+	scopeFolder
+	scopeCollection
+)
+
+// variableDefaults -
+type variableDefaults struct {
+	keys   []string
+	values map[string]*EnvironmentItem
+	scopes map[string]int
+}
+
+// set keeps the value unless a scope of higher precedence, or an earlier
+// definition in the same scope, already defined the key.
+func (d *variableDefaults) set(item *EnvironmentItem, scope int) {
+	if !item.IsEnabled() || item.Key == "" {
+		return
+	}
+	if current, ok := d.scopes[item.Key]; ok {
+		if current >= scope {
+			return
+		}
+	} else {
+		d.keys = append(d.keys, item.Key)
+	}
+	d.values[item.Key] = item
+	d.scopes[item.Key] = scope
+}
+
+// CollectVariableDefaults gathers the defaults of the variables defined on the
+// collection, on its folders and for URL path variables, so that requests can
+// run without selecting an environment. Collection variables take precedence
+// over folder variables, which take precedence over URL variables.
+func CollectVariableDefaults(collection *PostmanCollection) []*EnvironmentItem {
+	defaults := &variableDefaults{values: map[string]*EnvironmentItem{}, scopes: map[string]int{}}
+	for _, variable := range collection.Variables {
+		defaults.set(variable, scopeCollection)
+	}
+	collectItemVariableDefaults(collection.Items, defaults)
+
+	items := make([]*EnvironmentItem, 0, len(defaults.keys))
+	for _, key := range defaults.keys {
+		items = append(items, defaults.values[key])
+	}
+	return items
+}
+
+func collectItemVariableDefaults(items []*Item, defaults *variableDefaults) {
+	for _, item := range items {
+		if item.Request != nil {
+			var url URL
+			if err := json.Unmarshal(item.Request.URL, &url); err == nil {
+				for _, variable := range url.Variable {
+					if variable.Value != "" {
+						defaults.set(&EnvironmentItem{Key: variable.Key, Value: variable.Value, Enabled: true}, scopeURL)
+					}
+				}
+			}
+		}
+
+		if len(item.Items) > 0 {
+			for _, variable := range item.Variables {
+				defaults.set(variable, scopeFolder)
+			}
+			collectItemVariableDefaults(item.Items, defaults)
+		}
+	}
+}
+
+// SortVariables orders variables so that the ones other values reference
+// come before them, for tools resolving references in a single pass.
+// Variables keep their order where nothing requires otherwise, references in
+// a cycle can't all be satisfied.
+func SortVariables(variables []*EnvironmentItem) []*EnvironmentItem {
+	byKey := map[string]*EnvironmentItem{}
+	for _, variable := range variables {
+		if byKey[variable.Key] == nil {
+			byKey[variable.Key] = variable
+		}
+	}
+
+	placed := map[*EnvironmentItem]bool{}
+	visiting := map[*EnvironmentItem]bool{}
+	sorted := make([]*EnvironmentItem, 0, len(variables))
+	var place func(variable *EnvironmentItem)
+	place = func(variable *EnvironmentItem) {
+		if placed[variable] || visiting[variable] {
+			return
+		}
+		visiting[variable] = true
+		for _, match := range variableReferencePattern.FindAllStringSubmatch(string(variable.Value), -1) {
+			if referenced := byKey[match[1]]; referenced != nil {
+				place(referenced)
+			}
+		}
+		visiting[variable] = false
+		placed[variable] = true
+		sorted = append(sorted, variable)
+	}
+	for _, variable := range variables {
+		place(variable)
+	}
+	return sorted
+}
+
+// UndefinedVariables returns an error for each request of the collection
+// using variables that neither defined, the collection, its folders nor its
+// requests and their scripts define. Scripts may set a variable any request
+// runs after, so what they set counts as defined for the whole collection.
+func UndefinedVariables(collection *PostmanCollection, defined map[string]bool, opts *Options) []*ConvertError {
+	known := map[string]bool{}
+	for name := range defined {
+		known[name] = true
+	}
+	for _, variable := range CollectVariableDefaults(collection) {
+		known[variable.Key] = true
+	}
+	collectDefinedVariables(collection.Items, known)
+
+	var errs []*ConvertError
+	checkItemVariables(collection.Items, collection.Auth, known, opts, &errs)
+	return errs
+}
+
+// collectDefinedVariables adds the variables the items and their scripts
+// define to known.
+func collectDefinedVariables(items []*Item, known map[string]bool) {
+	for _, item := range items {
+		for _, variable := range item.Variables {
+			if variable.IsEnabled() && variable.Key != "" {
+				known[variable.Key] = true
+			}
+		}
+		for _, variable := range scriptVariables(item.Events) {
+			known[variable] = true
+		}
+		collectDefinedVariables(item.Items, known)
+	}
+}
+
+func checkItemVariables(items []*Item, inheritedAuth *Auth, known map[string]bool, opts *Options, errs *[]*ConvertError) {
+	for _, item := range EnabledItems(items, opts) {
+		if item.Request != nil {
+			var undefined []string
+			for _, variable := range referencedVariables(item, ResolveAuth(item.Request.Auth, inheritedAuth)) {
+				if !known[variable] {
+					undefined = append(undefined, variable)
+				}
+			}
+			if len(undefined) > 0 {
+				*errs = append(*errs, &ConvertError{Request: item.Name, Err: fmt.Errorf("undefined variables %s", strings.Join(undefined, ", "))})
+			}
+		}
+		checkItemVariables(item.Items, ResolveAuth(item.Auth, inheritedAuth), known, opts, errs)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"postman-collection-migraton/converter"
+)
+
+// Formats environments can be written in.
+const (
+	envFormatDotenv = "dotenv"
+	envFormatJSON   = "json"
+)
+
+// httpClientEnvFileName is the environment file httpYac reads in the json
+// environment format.
+const httpClientEnvFileName = "http-client.env.json"
+
+// httpClientPrivateEnvFileName is the environment file httpYac merges into
+// http-client.env.json, meant for values not to be committed.
+const httpClientPrivateEnvFileName = "http-client.private.env.json"
+
+// Names the globals are written under. httpYac applies the variables of the
+// $shared environment of http-client.env.json to all environments.
+const (
+	globalsEnvironmentName       = "globals"
+	globalsSharedEnvironmentName = "$shared"
+)
+
+// listEnvironmentFiles lists the environment files in the environments
+// directory, or the single environment file given, and the globals file.
+func listEnvironmentFiles(environmentsDir string, opts *Options) ([]string, error) {
+	var environmentFileNames []string
+	if environmentsDir != "" {
+		var err error
+		if environmentFileNames, err = listFiles(environmentsDir, false, ".json"); err != nil {
+			return nil, err
+		}
+	}
+	if opts.GlobalsFile != "" && !slices.Contains(environmentFileNames, opts.GlobalsFile) {
+		environmentFileNames = append(environmentFileNames, opts.GlobalsFile)
+	}
+	return environmentFileNames, nil
+}
+
+// convertEnvironments converts the environment files into outputDir and
+// counts them, and what went wrong, in summary.
+func convertEnvironments(environmentFileNames []string, outputDir string, opts *Options, summary *Summary) {
+	converted, errs := convertEnvironmentFiles(environmentFileNames, outputDir, opts)
+	for _, err := range errs {
+		logConversionError(err)
+	}
+	summary.Environments += converted
+	summary.Errors += len(errs)
+}
+
+// environmentVariableNames returns the names of the variables the
+// environment files define. Files that can't be read are left out,
+// converting them reports why, as it reports their warnings.
+func environmentVariableNames(environmentFileNames []string) map[string]bool {
+	names := map[string]bool{}
+	for _, environmentFileName := range environmentFileNames {
+		environment, err := readEnvironmentFile(environmentFileName, nil)
+		if err != nil {
+			continue
+		}
+		for _, value := range environment.Values {
+			if value.IsEnabled() {
+				names[value.Key] = true
+			}
+		}
+	}
+	return names
+}
+
+// convertEnvironmentFiles converts the environment files into outputDir in
+// the configured format and returns how many were converted and what went
+// wrong.
+func convertEnvironmentFiles(environmentFileNames []string, outputDir string, opts *Options) (int, []error) {
+	var converted int
+	var errs []error
+	var environments []*converter.PostmanEnvironment
+	var convertedFileNames []string
+	// Files holding secret values, to keep out of version control
+	var secretFileNames []string
+	for _, environmentFileName := range environmentFileNames {
+		environment, err := readEnvironmentFile(environmentFileName, &opts.Options)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if opts.SortVariables {
+			environment.Values = converter.SortVariables(environment.Values)
+		}
+
+		// Name the globals after what they become, whatever the workspace
+		if environmentFileName == opts.GlobalsFile {
+			environment.Name = globalsEnvironmentName
+			if opts.EnvFormat == envFormatJSON {
+				environment.Name = globalsSharedEnvironmentName
+			}
+		}
+
+		// Collect the environments to write them together into one file
+		if opts.EnvFormat == envFormatJSON {
+			environments = append(environments, environment)
+			convertedFileNames = append(convertedFileNames, environmentFileName)
+			continue
+		}
+
+		// Write the environment JSON data to a .env file
+		envName := converter.SanitizeName(environment.Name) + ".env"
+		envFileName := filepath.Join(outputDir, envName)
+		if err := writeFile(envFileName, []byte(environment.Dotenv(opts.MaskSecrets)), opts); err != nil {
+			errs = append(errs, &WriteError{Path: envFileName, Err: err})
+			continue
+		}
+		generated.add(&manifestFile{Path: envFileName, Sources: []string{environmentFileName}})
+		if !opts.MaskSecrets && len(environment.Secrets()) > 0 {
+			secretFileNames = append(secretFileNames, envName)
+		}
+
+		logInfof("Converted environment: %s", filepath.Base(environmentFileName))
+		converted++
+	}
+
+	if len(environments) > 0 {
+		if err := writeHTTPClientEnvFile(environments, outputDir, opts); err != nil {
+			return converted, append(errs, &WriteError{Path: filepath.Join(outputDir, httpClientEnvFileName), Err: err})
+		}
+		generated.add(&manifestFile{Path: filepath.Join(outputDir, httpClientEnvFileName), Sources: convertedFileNames})
+
+		// Move the secrets aside into the private file
+		if opts.IgnoreSecrets && !opts.MaskSecrets {
+			written, err := writeHTTPClientPrivateEnvFile(environments, outputDir, opts)
+			if err != nil {
+				return converted, append(errs, &WriteError{Path: filepath.Join(outputDir, httpClientPrivateEnvFileName), Err: err})
+			}
+			if written {
+				secretFileNames = append(secretFileNames, httpClientPrivateEnvFileName)
+				generated.add(&manifestFile{Path: filepath.Join(outputDir, httpClientPrivateEnvFileName), Sources: convertedFileNames})
+			}
+		}
+
+		for _, environmentFileName := range convertedFileNames {
+			logInfof("Converted environment: %s", filepath.Base(environmentFileName))
+		}
+		converted += len(environments)
+	}
+
+	if opts.IgnoreSecrets && len(secretFileNames) > 0 {
+		if err := writeGitignore(outputDir, secretFileNames, opts); err != nil {
+			errs = append(errs, &WriteError{Path: filepath.Join(outputDir, ".gitignore"), Err: err})
+		} else {
+			generated.add(&manifestFile{Path: filepath.Join(outputDir, ".gitignore"), Sources: environmentFileNames})
+		}
+	}
+	return converted, errs
+}
+
+// readEnvironmentFile reads and parses the environment file, reporting
+// warnings through opts, which may be nil.
+func readEnvironmentFile(environmentFileName string, opts *converter.Options) (*converter.PostmanEnvironment, error) {
+	// Read the environment JSON file
+	environmentData, err := os.ReadFile(environmentFileName)
+	if err != nil {
+		return nil, &converter.ParseError{Path: environmentFileName, Err: err}
+	}
+
+	// Parse the JSON data
+	environment, err := converter.ParseEnvironment(environmentData, opts)
+	if err != nil {
+		return nil, withPath(err, environmentFileName)
+	}
+	return environment, nil
+}
+
+// writeHTTPClientEnvFile writes all environments into the
+// http-client.env.json file httpYac picks environments up from by name.
+func writeHTTPClientEnvFile(environments []*converter.PostmanEnvironment, outputDir string, opts *Options) error {
+	envs := map[string]map[string]string{}
+	for _, environment := range environments {
+		envs[environment.Name] = environment.Variables(opts.MaskSecrets || opts.IgnoreSecrets)
+	}
+
+	data, err := json.MarshalIndent(envs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(outputDir, httpClientEnvFileName), append(data, '\n'), opts)
+}
+
+// writeHTTPClientPrivateEnvFile writes the secret values of the environments
+// into http-client.private.env.json and reports whether there were any.
+func writeHTTPClientPrivateEnvFile(environments []*converter.PostmanEnvironment, outputDir string, opts *Options) (bool, error) {
+	envs := map[string]map[string]string{}
+	for _, environment := range environments {
+		if secrets := environment.Secrets(); len(secrets) > 0 {
+			envs[environment.Name] = secrets
+		}
+	}
+	if len(envs) == 0 {
+		return false, nil
+	}
+
+	data, err := json.MarshalIndent(envs, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, writeFile(filepath.Join(outputDir, httpClientPrivateEnvFileName), append(data, '\n'), opts)
+}
+
+// writeGitignore writes a .gitignore to outputDir ignoring the given files.
+func writeGitignore(outputDir string, fileNames []string, opts *Options) error {
+	sb := strings.Builder{}
+	sb.WriteString("# Converted environments holding secret values\n")
+	escape := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "!", `\!`, "#", `\#`)
+	for _, fileName := range fileNames {
+		sb.WriteString("/" + escape.Replace(fileName) + "\n")
+	}
+	return writeFile(filepath.Join(outputDir, ".gitignore"), []byte(sb.String()), opts)
+}
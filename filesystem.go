@@ -0,0 +1,47 @@
+package main
+
+import "os"
+
+// FileSystem - where the converted files are written, the OS file system
+// unless Options.FS says otherwise
+type FileSystem interface {
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	ReadFile(name string) ([]byte, error)
+	// Remove removes a file or an empty directory
+	Remove(name string) error
+	// Whether a file or directory exists at name
+	Exists(name string) bool
+}
+
+// osFileSystem -
+type osFileSystem struct{}
+
+func (osFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFileSystem) Exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// fileSystem returns the file system to write the output to.
+func (o *Options) fileSystem() FileSystem {
+	if o.FS == nil {
+		return osFileSystem{}
+	}
+	return o.FS
+}
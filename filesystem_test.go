@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// memFileSystem keeps what is written in memory, so that tests can check
+// the layout and content of the output without touching the disk. Paths
+// are cleaned, directories are created implicitly like os.MkdirAll would.
+type memFileSystem struct {
+	mu    sync.Mutex
+	Files map[string][]byte
+	Dirs  map[string]bool
+}
+
+func newMemFileSystem() *memFileSystem {
+	return &memFileSystem{Files: map[string][]byte{}, Dirs: map[string]bool{}}
+}
+
+func (m *memFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := filepath.Clean(path); !m.Dirs[dir]; dir = filepath.Dir(dir) {
+		m.Dirs[dir] = true
+	}
+	return nil
+}
+
+func (m *memFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if dir := filepath.Dir(filepath.Clean(name)); dir != "." && !m.Dirs[dir] {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	m.Files[filepath.Clean(name)] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memFileSystem) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.Files[filepath.Clean(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *memFileSystem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if _, ok := m.Files[name]; ok {
+		delete(m.Files, name)
+		return nil
+	}
+	if !m.Dirs[name] {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	prefix := name + string(filepath.Separator)
+	for path := range m.Files {
+		if strings.HasPrefix(path, prefix) {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	for dir := range m.Dirs {
+		if strings.HasPrefix(dir, prefix) {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	delete(m.Dirs, name)
+	return nil
+}
+
+func (m *memFileSystem) Exists(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	_, ok := m.Files[name]
+	return ok || m.Dirs[name]
+}
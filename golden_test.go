@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected output in testdata/golden")
+
+// goldenDir holds what the fixtures of testdata/collections and
+// testdata/environments convert into, below collections and environments.
+const goldenDir = "testdata/golden"
+
+func TestGolden(t *testing.T) {
+	fsys := newMemFileSystem()
+	opts := &Options{FS: fsys, EnvFormat: envFormatDotenv}
+
+	collectionFileNames, err := listFiles(filepath.Join("testdata", "collections"), true, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := convertCollectionFiles(collectionFileNames, filepath.Join("testdata", "collections"), "collections", opts)
+	if summary.Errors > 0 || summary.Collections != len(collectionFileNames) {
+		t.Errorf("convertCollectionFiles() summary = %+v", summary)
+	}
+
+	environmentFileNames, err := listFiles(filepath.Join("testdata", "environments"), false, ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.MkdirAll("environments", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if converted, errs := convertEnvironmentFiles(environmentFileNames, "environments", opts); len(errs) > 0 || converted != len(environmentFileNames) {
+		t.Errorf("convertEnvironmentFiles() = %d, %v", converted, errs)
+	}
+
+	got := map[string]string{}
+	for name, data := range fsys.Files {
+		got[filepath.ToSlash(name)] = string(data)
+	}
+
+	if *update {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range got {
+			fileName := filepath.Join(goldenDir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	want := map[string]string{}
+	err = filepath.WalkDir(goldenDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(goldenDir, path)
+		want[filepath.ToSlash(name)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reading %s: %v, run go test -run TestGolden -update to create it", goldenDir, err)
+	}
+
+	for name, content := range want {
+		if _, ok := got[name]; !ok {
+			t.Errorf("%s was not written", name)
+		} else if got[name] != content {
+			t.Errorf("%s differs from %s:\n%s", name, goldenDir, diffLines(got[name], content))
+		}
+	}
+	var unexpected []string
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			unexpected = append(unexpected, name)
+		}
+	}
+	slices.Sort(unexpected)
+	for _, name := range unexpected {
+		t.Errorf("%s is not in %s", name, goldenDir)
+	}
+}
+
+// diffLines lists the first line where got and want differ.
+func diffLines(got, want string) string {
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var gotLine, wantLine string
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine || i >= len(gotLines) || i >= len(wantLines) {
+			return "line " + strconv.Itoa(i+1) + ":\n  got  " + strconv.Quote(gotLine) + "\n  want " + strconv.Quote(wantLine)
+		}
+	}
+	return ""
+}
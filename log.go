@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"postman-collection-migraton/converter"
+)
+
+// Levels of the messages logged, from the most to the least important.
+const (
+	levelError = iota
+	levelWarn
+	levelInfo
+)
+
+// logLevel is the least important level still logged.
+var logLevel = levelWarn
+
+// logger writes to stderr, so that stdout only carries the tool's output
+// such as the summary or the converted requests in stdin mode.
+var logger = log.New(os.Stderr, "", 0)
+
+func logErrorf(format string, args ...interface{}) {
+	logf(levelError, format, args...)
+}
+
+func logWarnf(format string, args ...interface{}) {
+	logf(levelWarn, format, args...)
+}
+
+func logInfof(format string, args ...interface{}) {
+	logf(levelInfo, format, args...)
+}
+
+func logf(level int, format string, args ...interface{}) {
+	if level <= logLevel {
+		progress.suspend(func() {
+			logger.Printf(format, args...)
+		})
+	}
+}
+
+// logConversionError prints an error the conversion returned, worded after
+// its type.
+func logConversionError(err error) {
+	var parseErr *converter.ParseError
+	var convertErr *converter.ConvertError
+	var writeErr *WriteError
+	switch {
+	case errors.As(err, &parseErr):
+		logErrorf("Error parsing %s: %v", parseErr.Path, parseErr.Err)
+	case errors.As(err, &convertErr):
+		logErrorf("Error converting request %s of %s to httpYac: %v", convertErr.Request, convertErr.Path, convertErr.Err)
+	case errors.As(err, &writeErr):
+		logErrorf("Error writing %s: %v", writeErr.Path, writeErr.Err)
+	default:
+		logErrorf("Error: %v", err)
+	}
+}
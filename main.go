@@ -1,244 +1,344 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+
+	"postman-collection-migraton/converter"
 )
 
-// URL -
-type URL struct {
-	Raw  string   `json:"raw"`
-	Host []string `json:"host"`
-	Path []string `json:"path"`
+// Options -
+type Options struct {
+	converter.Options
+	OutputDir          string
+	DryRun             bool
+	Stdin              bool
+	EnvFormat          string
+	Verbose            bool
+	JSONSummary        bool
+	Jobs               int
+	ConfigFile         string
+	IgnoreSecrets      bool
+	Clean              bool
+	CollectionsSubdir  string
+	EnvironmentsSubdir string
+	Progress           bool
+	Match              string
+	GlobalsFile        string
+	Watch              bool
+	StrictVariables    bool
+	StdoutManifest     bool
+	// File system the output is written to, the OS one when nil
+	FS FileSystem
+	// Variables the environments define, requests are only checked for
+	// undefined variables when set
+	DefinedVariables map[string]bool
 }
 
-// Header -
-type Header struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-}
+// Subcommands converting only one kind of file.
+const (
+	collectionsCommand  = "convert-collections"
+	environmentsCommand = "convert-environments"
+)
 
-// Body -
-type Body struct {
-	Raw  string `json:"raw"`
-	Mode string `json:"mode"`
-}
+func main() {
+	opts := &Options{}
+	flag.StringVar(&opts.OutputDir, "o", ".", "base directory to write the converted collections and environments to")
+	flag.StringVar(&opts.OutputDir, "output", ".", "same as -o")
+	flag.StringVar(&opts.CollectionsSubdir, "collections-subdir", "parsed-collections", "directory below the output directory to write the collections to, . for the output directory itself")
+	flag.StringVar(&opts.EnvironmentsSubdir, "environments-subdir", "parsed-environments", "directory below the output directory to write the environments to, . for the output directory itself")
+	flag.BoolVar(&opts.SingleFile, "single-file", false, "write all requests of a folder into a single .http file, separated by ###")
+	flag.BoolVar(&opts.Stdin, "stdin", false, "read a single collection from stdin and write the requests to stdout, same as passing - as the only argument")
+	flag.BoolVar(&opts.Clean, "clean", false, "remove the files previous runs wrote to the output directory of each collection, as listed in its "+outputListFileName+", before converting it")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "print the files that would be written without writing anything")
+	flag.StringVar(&opts.GlobalsFile, "globals", "", "Postman globals file to convert along with the environments, into globals.env or the $shared environment of "+httpClientEnvFileName)
+	flag.StringVar(&opts.EnvFormat, "env-format", envFormatDotenv, "format to write environments in: dotenv for one .env file per environment, json for a single "+httpClientEnvFileName)
+	flag.BoolVar(&opts.SortVariables, "sort-env", false, "write environment variables after the variables their values reference")
+	flag.BoolVar(&opts.MaskSecrets, "mask-secrets", false, "leave the values of secret environment variables out of the .env files")
+	flag.BoolVar(&opts.IgnoreSecrets, "ignore-secrets", false, "keep secret environment values out of git: list the .env files holding them in a .gitignore, or move them to "+httpClientPrivateEnvFileName+" in the json format")
+	flag.BoolVar(&opts.HeaderDescriptions, "header-descriptions", false, "keep header descriptions as comments above the headers")
+	flag.StringVar(&opts.Match, "match", "", "only convert the collection files whose name matches this glob, like 'billing*'")
+	flag.BoolVar(&opts.Watch, "watch", false, "keep running and convert the collection and environment files again when they change")
+	flag.BoolVar(&opts.Progress, "progress", false, "show how many collections are converted, the default when stderr is a terminal and this is not a dry run")
+	flag.IntVar(&opts.Jobs, "j", runtime.GOMAXPROCS(0), "number of collections to convert in parallel")
+	flag.BoolVar(&opts.Flatten, "flatten", false, "write the requests of folders into the collection directory, prefixing their file names with the folder path")
+	flag.IntVar(&opts.MaxDepth, "max-depth", 0, "number of folder levels to create directories for, deeper folders are flattened into file names; 0 for no limit")
+	flag.StringVar(&opts.FlattenSeparator, "flatten-separator", "__", "separator between the folder names and the request name in flatten mode")
+	flag.BoolVar(&opts.CommonHeaders, "common-headers", false, "define the headers all requests of a folder send with the same value once, in a _common file the requests import")
+	flag.BoolVar(&opts.SharedAuth, "shared-auth", false, "define the credentials of the collection auth once in the collection .env file and its headers once in .httpyac.json, instead of on every request using it")
+	flag.BoolVar(&opts.Chain, "chain", false, "order the requests of a folder after the requests setting the variables they use and reference those with # @ref")
+	flag.StringVar(&opts.FilesDir, "files-dir", "", "directory, relative to the .http files, that relative paths of uploaded files are resolved against")
+	flag.StringVar(&opts.FileNameTemplate, "filename-template", "", "name the request files after a template of the placeholders {name}, {method} and {host}, e.g. {method}_{name}")
+	flag.StringVar(&opts.Extension, "ext", ".http", "file extension of the converted requests, e.g. .rest")
+	flag.BoolVar(&opts.IncludeDisabled, "include-disabled", false, "convert disabled requests and folders commented out instead of skipping them")
+	flag.BoolVar(&opts.NormalizeHeaders, "normalize-headers", false, "write header keys in their canonical form, e.g. content-type as Content-Type")
+	flag.BoolVar(&opts.StrictVariables, "strict-variables", false, "fail requests using variables no environment or collection defines, instead of warning about them when environments are converted")
+	flag.StringVar(&opts.ConfigFile, "config", "", "JSON file with regular expression substitutions to apply to the URLs, headers and bodies of the requests")
+	flag.BoolVar(&opts.StdoutManifest, "stdout-manifest", false, "print the written files and the collections, requests and environments they were converted from as JSON, the summary goes to stderr instead")
+	flag.BoolVar(&opts.JSONSummary, "json", false, "print the final summary as JSON")
+	flag.BoolVar(&opts.Verbose, "v", false, "report every converted file and request, not only errors and the summary")
+	flag.Usage = func() {
+		fmt.Println("Usage: postman-to-httpyac-converter [flags] <collections-dir|collection-file> [<environments-dir|environment-file>]")
+		fmt.Println("       postman-to-httpyac-converter [flags] " + collectionsCommand + " <collections-dir|collection-file>")
+		fmt.Println("       postman-to-httpyac-converter [flags] " + environmentsCommand + " <environments-dir|environment-file>")
+		fmt.Println("       postman-to-httpyac-converter [flags] - < collection.json > requests.http")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-// Request -
-type Request struct {
-	Method string          `json:"method"`
-	URL    json.RawMessage `json:"url"`
-	Header []*Header       `json:"header"`
-	Body   json.RawMessage `json:"body"`
-}
+	// Flags may follow the subcommands converting only one kind of file
+	command := ""
+	if flag.Arg(0) == collectionsCommand || flag.Arg(0) == environmentsCommand {
+		command = flag.Arg(0)
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+	}
 
-// Item -
-type Item struct {
-	Name    string   `json:"name"`
-	Request *Request `json:"request"`
-	Items   []*Item  `json:"item"`
-}
+	if opts.Verbose {
+		logLevel = levelInfo
+	}
+	if !isFlagSet("progress") {
+		// Dry runs list the files on stdout, which may share the terminal
+		opts.Progress = isTerminal(os.Stderr) && !opts.DryRun
+	}
+	opts.Warnf = logWarnf
 
-// PostmanCollection -
-type PostmanCollection struct {
-	Items []*Item `json:"item"`
-}
+	if strings.Trim(opts.Extension, ".") == "" {
+		logErrorf("Invalid file extension %q", opts.Extension)
+		os.Exit(1)
+	}
+	if !strings.HasPrefix(opts.Extension, ".") {
+		opts.Extension = "." + opts.Extension
+	}
 
-// EnvironmentItem -
-type EnvironmentItem struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-}
+	if opts.ConfigFile != "" {
+		configData, err := os.ReadFile(opts.ConfigFile)
+		if err != nil {
+			logErrorf("Error reading config file %s: %v", opts.ConfigFile, err)
+			os.Exit(1)
+		}
+		config, err := converter.ParseConfig(configData)
+		if err != nil {
+			logErrorf("Error reading config file %s: %v", opts.ConfigFile, err)
+			os.Exit(1)
+		}
+		opts.Config = config
+	}
 
-// PostmanEnvironment -
-type PostmanEnvironment struct {
-	Name   string             `json:"name"`
-	Values []*EnvironmentItem `json:"values"`
-}
+	if opts.StdoutManifest {
+		reportOutput = os.Stderr
+	}
 
-func (e *PostmanEnvironment) String() string {
-	sb := strings.Builder{}
-	for _, v := range e.Values {
-		sb.WriteString(fmt.Sprintf("%s=%s\n", v.Key, v.Value))
+	// Read a single collection from stdin and write the requests to stdout
+	if opts.Stdin || (command == "" && flag.NArg() == 1 && flag.Arg(0) == "-") {
+		if !convertStdin(opts) {
+			os.Exit(1)
+		}
+		return
 	}
-	return sb.String()
-}
 
-func main() {
-	if len(os.Args) != 3 {
-		fmt.Println("Usage: postman-to-httpyac-converter <collections-dir> <environments-dir>")
+	// Leave the directory of the kind of file not converted empty
+	var collectionsDir, environmentsDir string
+	switch {
+	case command == collectionsCommand && flag.NArg() == 1:
+		collectionsDir = flag.Arg(0)
+	case command == environmentsCommand && flag.NArg() == 1:
+		environmentsDir = flag.Arg(0)
+	case command == "" && (flag.NArg() == 1 || flag.NArg() == 2):
+		collectionsDir, environmentsDir = flag.Arg(0), flag.Arg(1)
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+	if opts.EnvFormat != envFormatDotenv && opts.EnvFormat != envFormatJSON {
+		logErrorf("Unknown environment format %q, use %s or %s", opts.EnvFormat, envFormatDotenv, envFormatJSON)
 		os.Exit(1)
 	}
 
-	collectionsDir := os.Args[1]
-	environmentsDir := os.Args[2]
-
-	// Read all collection files in the collections directory
-	collectionFiles, err := os.ReadDir(collectionsDir)
-	if err != nil {
-		fmt.Printf("Error reading collections directory: %v\n", err)
+	if err := converter.ValidateFileNameTemplate(opts.FileNameTemplate); err != nil {
+		logErrorf("Invalid -filename-template %q: %v", opts.FileNameTemplate, err)
 		os.Exit(1)
 	}
 
-	// Read all environment files in the environments directory
-	environmentFiles, err := os.ReadDir(environmentsDir)
-	if err != nil {
-		fmt.Printf("Error reading environments directory: %v\n", err)
+	if _, err := filepath.Match(opts.Match, ""); err != nil {
+		logErrorf("Invalid -match pattern %q: %v", opts.Match, err)
 		os.Exit(1)
 	}
 
-	// Create subdirectories for collections and environments
-	collectionsSubdir := "parsed-collections"
-	environmentsSubdir := "parsed-environments"
-	err = os.MkdirAll(collectionsSubdir, os.ModePerm)
+	for _, subdir := range []string{opts.CollectionsSubdir, opts.EnvironmentsSubdir} {
+		if !filepath.IsLocal(subdir) {
+			logErrorf("Invalid output subdirectory %q, it must be a relative path within the output directory", subdir)
+			os.Exit(1)
+		}
+	}
+
+	if opts.GlobalsFile != "" {
+		opts.GlobalsFile = filepath.Clean(opts.GlobalsFile)
+	}
+
+	collectionFileNames, err := listCollectionFiles(collectionsDir, opts)
 	if err != nil {
-		fmt.Printf("Error creating collections subdirectory: %v\n", err)
+		logErrorf("Error reading collections directory: %v", err)
 		os.Exit(1)
 	}
-	err = os.MkdirAll(environmentsSubdir, os.ModePerm)
+	environmentFileNames, err := listEnvironmentFiles(environmentsDir, opts)
 	if err != nil {
-		fmt.Printf("Error creating environments subdirectory: %v\n", err)
+		logErrorf("Error reading environments directory: %v", err)
 		os.Exit(1)
 	}
 
-	// Process collections
-	for _, fileInfo := range collectionFiles {
-		if !fileInfo.IsDir() && strings.HasSuffix(fileInfo.Name(), ".json") {
-			collectionFileName := filepath.Join(collectionsDir, fileInfo.Name())
-			outputDir := filepath.Join(collectionsSubdir, strings.TrimSuffix(sanitizeName(fileInfo.Name()), ".postman_collection.json"))
-
-			// Create subdirectory for the collection
-			err := os.MkdirAll(outputDir, os.ModePerm)
-			if err != nil {
-				fmt.Printf("Error creating collection subdirectory: %v\n", err)
-				continue
-			}
+	// Check the requests against the environments they will run with
+	if len(environmentFileNames) > 0 || opts.StrictVariables {
+		opts.DefinedVariables = environmentVariableNames(environmentFileNames)
+	}
 
-			// Read the Postman Collection 2.1 JSON file
-			collectionData, err := os.ReadFile(collectionFileName)
-			if err != nil {
-				fmt.Printf("Error reading collection file: %v\n", err)
-				continue
-			}
+	// Create subdirectories for collections and environments
+	collectionsSubdir := filepath.Join(opts.OutputDir, opts.CollectionsSubdir)
+	environmentsSubdir := filepath.Join(opts.OutputDir, opts.EnvironmentsSubdir)
+	if collectionsDir != "" {
+		if err := makeDir(collectionsSubdir, opts); err != nil {
+			logErrorf("Error creating collections subdirectory: %v", err)
+			os.Exit(1)
+		}
+	}
+	if len(environmentFileNames) > 0 {
+		if err := makeDir(environmentsSubdir, opts); err != nil {
+			logErrorf("Error creating environments subdirectory: %v", err)
+			os.Exit(1)
+		}
+	}
 
-			// Parse the JSON data
-			var collection PostmanCollection
-			if err := json.Unmarshal(collectionData, &collection); err != nil {
-				fmt.Printf("Error parsing collection %s JSON: %v\n", collectionFileName, err)
-				continue
-			}
+	// Process collections
+	summary := &Summary{}
+	if collectionsDir != "" {
+		summary = convertCollectionFiles(collectionFileNames, collectionsDir, collectionsSubdir, opts)
+	}
 
-			// Convert and save collection requests
-			convertAndSaveCollection(collection.Items, outputDir)
+	// Process environments
+	if len(environmentFileNames) > 0 {
+		convertEnvironments(environmentFileNames, environmentsSubdir, opts, summary)
+	}
 
-			fmt.Printf("Converted collection: %s\n", fileInfo.Name())
+	if err := summary.Print(reportOutput, opts.JSONSummary); err != nil {
+		logErrorf("Error printing summary: %v", err)
+	}
+	if opts.StdoutManifest {
+		if err := generated.print(os.Stdout); err != nil {
+			logErrorf("Error printing manifest: %v", err)
 		}
 	}
 
-	// Process environments
-	for _, fileInfo := range environmentFiles {
-		if !fileInfo.IsDir() && strings.HasSuffix(fileInfo.Name(), ".json") {
-			environmentFileName := filepath.Join(environmentsDir, fileInfo.Name())
-
-			// Read the environment JSON file
-			environmentData, err := os.ReadFile(environmentFileName)
-			if err != nil {
-				fmt.Printf("Error reading environment file: %v\n", err)
-				continue
-			}
+	if opts.Watch {
+		watch(collectionsDir, environmentsDir, collectionsSubdir, environmentsSubdir, opts)
+	}
+	if summary.Errors > 0 {
+		os.Exit(1)
+	}
+}
 
-			// Parse the JSON data
-			var environment PostmanEnvironment
-			if err := json.Unmarshal(environmentData, &environment); err != nil {
-				fmt.Printf("Error parsing environment %s JSON: %v\n", environmentFileName, err)
-				continue
-			}
+// stdinName names the collection read from stdin in errors.
+const stdinName = "collection from stdin"
 
-			// Write the environment JSON data to a .env file
-			envFileName := filepath.Join(environmentsSubdir, sanitizeName(environment.Name+".env"))
-			err = os.WriteFile(envFileName, []byte(environment.String()), 0644)
-			if err != nil {
-				fmt.Printf("Error writing .env file for environment %s: %v\n", fileInfo.Name(), err)
-			}
+// convertStdin converts the collection read from stdin into a single
+// .http document on stdout. Errors go to stderr to keep stdout clean.
+func convertStdin(opts *Options) bool {
+	collectionData, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		logConversionError(&converter.ParseError{Path: stdinName, Err: err})
+		return false
+	}
 
-			fmt.Printf("Converted environment: %s\n", fileInfo.Name())
-		}
+	converted, err := converter.ConvertCollection(collectionData, &opts.Options)
+	if err != nil {
+		logConversionError(withPath(err, stdinName))
+		return false
 	}
-}
 
-func convertAndSaveCollection(items []*Item, outputDir string) {
-	// Iterate through each request in the collection and write it to a separate .http file
-	for _, item := range items {
-		// First level request in collection
-		if item.Request != nil {
-			// Create an HTTPYac request and add environment variables
-			httpYacRequest, err := convertToHTTPYacRequest(item.Request)
-			if err != nil {
-				fmt.Printf("Error converting request to httpYac: %v\n", err)
-				continue
-			}
+	if len(converted) == 0 {
+		logWarnf("Warning: %s has no requests to convert", stdinName)
+	}
 
-			// Write the HTTPYac request to a separate .http file
-			requestFileName := filepath.Join(outputDir, sanitizeName(item.Name+".http"))
-			err = ioutil.WriteFile(requestFileName, []byte(httpYacRequest), 0644)
-			if err != nil {
-				fmt.Printf("Error writing .http file for request %s: %v\n", item.Name, err)
-			}
+	// Open the region of each folder at its first request
+	var httpYacRequests []string
+	var failed int
+	var folders []*converter.Item
+	for _, request := range converted {
+		if request.Err != nil {
+			logConversionError(withPath(request.Err, stdinName))
+			failed++
+			continue
 		}
 
-		// Subfolder request in collection
-		if len(item.Items) > 0 {
-			nestedOutputDir := filepath.Join(outputDir, sanitizeName(item.Name))
-			// Create subdirectory for the collection
-			err := os.MkdirAll(nestedOutputDir, os.ModePerm)
-			if err != nil {
-				fmt.Printf("Error creating collection subdirectory: %v\n", err)
-				continue
+		var banners string
+		for i, folder := range request.Folders {
+			if i >= len(folders) || folders[i] != folder {
+				banners += converter.FolderBanner(folder)
 			}
-
-			convertAndSaveCollection(item.Items, nestedOutputDir)
 		}
+		folders = request.Folders
+		httpYacRequests = append(httpYacRequests, banners+request.Content)
 	}
+	fmt.Print(converter.JoinRequests(httpYacRequests))
+	return failed == 0
 }
 
-func sanitizeName(fileName string) string {
-	sanitizedFileName := strings.Map(func(r rune) rune {
-		switch r {
-		case ':', '/', '\\', '?', '*', '<', '>', '|', '"':
-			return '_'
-		}
-		return r
-	}, fileName)
-	return sanitizedFileName
-}
-
-func convertToHTTPYacRequest(request *Request) (string, error) {
-	// Parse the URL
-	var url URL
-	if err := json.Unmarshal(request.URL, &url); err != nil {
-		url.Raw = string(request.URL)
+// listFiles returns the files in dir with one of the extensions, including
+// those in nested directories when recursive is set, or dir itself when it
+// names a single file rather than a directory.
+func listFiles(dir string, recursive bool, extensions ...string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
 	}
-
-	sb := strings.Builder{}
-
-	sb.WriteString(fmt.Sprintf("%s %s\n", request.Method, url.Raw))
-	for _, header := range request.Header {
-		sb.WriteString(fmt.Sprintf("%s: %s\n", header.Key, header.Value))
+	if !info.IsDir() {
+		return []string{dir}, nil
 	}
 
-	sb.WriteString("\n")
-	if request.Body != nil {
-		var body Body
-		if err := json.Unmarshal(request.Body, &body); err != nil {
-			body.Raw = string(request.Body)
+	var fileNames []string
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			// Skip nested directories unless asked to, and hidden ones like .git always
+			if path != dir && (!recursive || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if slices.Contains(extensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+			fileNames = append(fileNames, path)
+		}
+		return nil
+	})
+	return fileNames, err
+}
+
+// matchingFileNames returns the file names whose base name matches pattern.
+// The pattern was validated before, so matching cannot fail.
+func matchingFileNames(fileNames []string, pattern string) []string {
+	var matching []string
+	for _, fileName := range fileNames {
+		if matched, _ := filepath.Match(pattern, filepath.Base(fileName)); matched {
+			matching = append(matching, fileName)
 		}
-		sb.WriteString(body.Raw)
 	}
+	return matching
+}
 
-	httpYacRequest := sb.String()
-	return httpYacRequest, nil
+// isFlagSet reports whether the flag was passed on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+
+	"postman-collection-migraton/converter"
+)
+
+// manifest lists the files a run wrote and what they were converted from,
+// for -stdout-manifest.
+type manifest struct {
+	mu    sync.Mutex
+	files []*manifestFile
+}
+
+// manifestFile -
+type manifestFile struct {
+	Path string `json:"path"`
+	// Collection or environment files the file was converted from
+	Sources []string `json:"sources"`
+	// Suffix added to the name of the file to tell it from an identically
+	// named one, e.g. "-2"
+	Suffix string `json:"suffix,omitempty"`
+	// Requests the file holds, or whose body it holds
+	Requests []*converter.OutputRequest `json:"requests,omitempty"`
+}
+
+// generated is shared by the conversion workers.
+var generated = &manifest{}
+
+// add lists a written file.
+func (m *manifest) add(file *manifestFile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files = append(m.files, file)
+}
+
+// print writes the files listed so far as JSON, ordered by path, and starts
+// over for the next run in watch mode.
+func (m *manifest) print(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := m.files
+	if files == nil {
+		files = []*manifestFile{}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	m.files = nil
+	return json.NewEncoder(w).Encode(struct {
+		Files []*manifestFile `json:"files"`
+	}{files})
+}
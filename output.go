@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"postman-collection-migraton/converter"
+)
+
+// reportOutput receives the summary and what a dry run would do, stderr
+// when stdout carries the manifest.
+var reportOutput io.Writer = os.Stdout
+
+// WriteError - an output file or directory that could not be written
+type WriteError struct {
+	Path string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("writing %s: %v", e.Path, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// makeDir creates dir and its parents, unless this is a dry run.
+func makeDir(dir string, opts *Options) error {
+	if opts.DryRun {
+		return nil
+	}
+	return opts.fileSystem().MkdirAll(dir, os.ModePerm)
+}
+
+// outputListFileName is the file listing, relative to the output
+// directory of a collection file, what the last run wrote there.
+const outputListFileName = ".postman-to-httpyac.json"
+
+// outputList -
+type outputList struct {
+	Files []string `json:"files"`
+}
+
+// writeOutputList lists the files written below dir, for -clean to remove
+// on the next run.
+func writeOutputList(dir string, fileNames []string, opts *Options) error {
+	list := &outputList{}
+	for _, fileName := range fileNames {
+		relativeName, err := filepath.Rel(dir, fileName)
+		if err != nil {
+			continue
+		}
+		list.Files = append(list.Files, filepath.ToSlash(relativeName))
+	}
+	sort.Strings(list.Files)
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return &WriteError{Path: filepath.Join(dir, outputListFileName), Err: err}
+	}
+	if err := writeFile(filepath.Join(dir, outputListFileName), append(data, '\n'), opts); err != nil {
+		return &WriteError{Path: filepath.Join(dir, outputListFileName), Err: err}
+	}
+	return nil
+}
+
+// removeOutput removes the files the last run listed as written below dir,
+// and the directories left empty, or only prints their names on a dry run.
+// Directories without a list are left alone, this tool did not write them.
+func removeOutput(dir string, opts *Options) []error {
+	fsys := opts.fileSystem()
+	listFileName := filepath.Join(dir, outputListFileName)
+	data, err := fsys.ReadFile(listFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		if fsys.Exists(dir) {
+			logWarnf("Warning: not cleaning %s, it has no %s from a previous run", dir, outputListFileName)
+		}
+		return nil
+	}
+	if err != nil {
+		return []error{&WriteError{Path: listFileName, Err: err}}
+	}
+	list := &outputList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return []error{&WriteError{Path: listFileName, Err: err}}
+	}
+
+	var errs []error
+	dirs := map[string]bool{}
+	for _, relativeName := range append(list.Files, outputListFileName) {
+		// Keep a tampered list from reaching outside the directory
+		relativeName = filepath.FromSlash(relativeName)
+		if !filepath.IsLocal(relativeName) {
+			continue
+		}
+		fileName := filepath.Join(dir, relativeName)
+		for parent := filepath.Dir(relativeName); parent != "."; parent = filepath.Dir(parent) {
+			dirs[filepath.Join(dir, parent)] = true
+		}
+		if opts.DryRun {
+			if fsys.Exists(fileName) {
+				fmt.Fprintf(reportOutput, "Would remove %s\n", fileName)
+			}
+			continue
+		}
+		if err := fsys.Remove(fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, &WriteError{Path: fileName, Err: err})
+		}
+	}
+	if opts.DryRun {
+		return errs
+	}
+
+	// Remove the directories left empty, innermost first, keeping those
+	// holding anything else
+	sortedDirs := []string{dir}
+	for parent := range dirs {
+		sortedDirs = append(sortedDirs, parent)
+	}
+	sort.Slice(sortedDirs, func(i, j int) bool { return len(sortedDirs[i]) > len(sortedDirs[j]) })
+	for _, emptyDir := range sortedDirs {
+		_ = fsys.Remove(emptyDir)
+	}
+	return errs
+}
+
+// writeFile writes the file, or only prints its name on a dry run.
+func writeFile(fileName string, data []byte, opts *Options) error {
+	if opts.DryRun {
+		fmt.Fprintf(reportOutput, "Would write %s\n", fileName)
+		return nil
+	}
+	return opts.fileSystem().WriteFile(fileName, data, 0644)
+}
+
+// writeOutputFiles writes the files converted from source below outputDir,
+// creating the directories they are in, and returns the files written and
+// the ones that can't be. A dry run writes, and returns, none.
+func writeOutputFiles(outputDir, source string, files []*converter.OutputFile, opts *Options) ([]string, []error) {
+	var written []string
+	var errs []error
+	for _, file := range files {
+		fileName := filepath.Join(outputDir, file.Path)
+		if err := makeDir(filepath.Dir(fileName), opts); err != nil {
+			errs = append(errs, &WriteError{Path: fileName, Err: err})
+			continue
+		}
+		// Body files keep their bytes as they are
+		content := file.Content
+		if !file.Body {
+			content = withTrailingNewline(content)
+		}
+		if err := writeFile(fileName, []byte(content), opts); err != nil {
+			errs = append(errs, &WriteError{Path: fileName, Err: err})
+			continue
+		}
+		// A dry run only prints what it would write, nothing was written
+		if opts.DryRun {
+			continue
+		}
+		generated.add(&manifestFile{Path: fileName, Sources: []string{source}, Suffix: file.Suffix, Requests: file.Requests})
+		written = append(written, fileName)
+		logInfof("Wrote %s", fileName)
+	}
+	return written, errs
+}
+
+// withTrailingNewline ends non-empty content with a line break, unless it
+// ends with one already. What the content ends with is kept, as it may be
+// the body of the last request.
+func withTrailingNewline(content string) string {
+	if content == "" || strings.HasSuffix(content, "\n") {
+		return content
+	}
+	return content + "\n"
+}
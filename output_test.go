@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"postman-collection-migraton/converter"
+)
+
+func TestWriteOutputFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		file *converter.OutputFile
+		want string
+	}{
+		{
+			name: "newline added",
+			file: &converter.OutputFile{Path: "get.http", Content: "GET https://api.example.com"},
+			want: "GET https://api.example.com\n",
+		},
+		{
+			name: "trailing newlines of the body kept",
+			file: &converter.OutputFile{Path: "post.http", Content: "POST https://api.example.com\n\nline\n\n"},
+			want: "POST https://api.example.com\n\nline\n\n",
+		},
+		{
+			name: "CRLF kept",
+			file: &converter.OutputFile{Path: "crlf.http", Content: "POST https://api.example.com\n\nline\r\n"},
+			want: "POST https://api.example.com\n\nline\r\n",
+		},
+		{
+			name: "empty file left empty",
+			file: &converter.OutputFile{Path: "empty.http"},
+			want: "",
+		},
+		{
+			name: "body written byte for byte",
+			file: &converter.OutputFile{Path: "upload.body", Content: "\x89PNG\r\n\x1a", Body: true},
+			want: "\x89PNG\r\n\x1a",
+		},
+		{
+			name: "requests named like a body file",
+			file: &converter.OutputFile{Path: "get.body", Content: "GET https://api.example.com"},
+			want: "GET https://api.example.com\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newMemFileSystem()
+			written, errs := writeOutputFiles("out", "collection.json", []*converter.OutputFile{tt.file}, &Options{FS: fsys})
+			if len(errs) > 0 {
+				t.Fatalf("writeOutputFiles() errors = %v", errs)
+			}
+			name := filepath.Join("out", tt.file.Path)
+			if len(written) != 1 || written[0] != name {
+				t.Errorf("writeOutputFiles() written = %v, want %s", written, name)
+			}
+			if got := string(fsys.Files[name]); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.file.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutputFilesDryRun(t *testing.T) {
+	var report bytes.Buffer
+	reportOutput = &report
+	defer func() { reportOutput = os.Stdout }()
+	generated.print(io.Discard)
+
+	fsys := newMemFileSystem()
+	files := []*converter.OutputFile{{Path: "get.http", Content: "GET https://api.example.com"}}
+	written, errs := writeOutputFiles("out", "collection.json", files, &Options{FS: fsys, DryRun: true})
+	if len(errs) > 0 {
+		t.Fatalf("writeOutputFiles() errors = %v", errs)
+	}
+	if len(written) != 0 || len(fsys.Files) != 0 {
+		t.Errorf("writeOutputFiles() written = %v, files = %v, want nothing written", written, fsys.Files)
+	}
+	if want := "Would write " + filepath.Join("out", "get.http") + "\n"; report.String() != want {
+		t.Errorf("report = %q, want %q", report.String(), want)
+	}
+
+	// The manifest only lists the files a run wrote
+	var manifest strings.Builder
+	if err := generated.print(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	if got := manifest.String(); got != "{\"files\":[]}\n" {
+		t.Errorf("manifest = %s, want no files", got)
+	}
+}
+
+func TestRemoveOutput(t *testing.T) {
+	fsys := newMemFileSystem()
+	opts := &Options{FS: fsys}
+	dir := filepath.Join("out", "collection")
+	files := map[string]string{
+		filepath.Join(dir, "get.http"):           "GET https://api.example.com\n",
+		filepath.Join(dir, "Users", "list.http"): "GET https://api.example.com/users\n",
+		filepath.Join(dir, "notes.txt"):          "kept by hand\n",
+		filepath.Join("out", "outside.http"):     "GET https://api.example.com\n",
+		filepath.Join(dir, outputListFileName):   `{"files": ["get.http", "Users/list.http", "../outside.http"]}`,
+	}
+	for name, content := range files {
+		if err := fsys.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := fsys.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if errs := removeOutput(dir, opts); len(errs) > 0 {
+		t.Fatalf("removeOutput() errors = %v", errs)
+	}
+	for _, name := range []string{"get.http", filepath.Join("Users", "list.http"), "Users", outputListFileName} {
+		if fsys.Exists(filepath.Join(dir, name)) {
+			t.Errorf("%s was not removed", name)
+		}
+	}
+	for _, name := range []string{filepath.Join(dir, "notes.txt"), filepath.Join("out", "outside.http")} {
+		if !fsys.Exists(name) {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
+
+func TestRemoveOutputWithoutList(t *testing.T) {
+	fsys := newMemFileSystem()
+	opts := &Options{FS: fsys}
+	name := filepath.Join("out", "collection", "get.http")
+	if err := fsys.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile(name, []byte("GET https://api.example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := removeOutput(filepath.Dir(name), opts); len(errs) > 0 {
+		t.Fatalf("removeOutput() errors = %v", errs)
+	}
+	if !fsys.Exists(name) {
+		t.Errorf("%s was removed from a directory no run wrote", name)
+	}
+}
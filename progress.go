@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// progressBarWidth is the number of characters the bar itself takes.
+const progressBarWidth = 30
+
+// progressBar draws how many of the collection files are converted on the
+// last line of stderr. Messages logged meanwhile are written above it.
+type progressBar struct {
+	mu      sync.Mutex
+	enabled bool
+	done    int
+	total   int
+}
+
+// progress is shared by the conversion workers and the logger.
+var progress = &progressBar{}
+
+// start shows the bar for total files, if enabled is set.
+func (p *progressBar) start(total int, enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enabled = enabled
+	p.done = 0
+	p.total = total
+	p.draw()
+}
+
+// increment counts one more file as converted.
+func (p *progressBar) increment() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.draw()
+}
+
+// finish leaves the bar as it is and moves on to the next line.
+func (p *progressBar) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.enabled {
+		fmt.Fprintln(os.Stderr)
+	}
+	p.enabled = false
+}
+
+// suspend clears the bar while write runs and draws it again below what
+// write wrote.
+func (p *progressBar) suspend(write func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.enabled {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
+	write()
+	p.draw()
+}
+
+func (p *progressBar) draw() {
+	if !p.enabled {
+		return
+	}
+	filled := progressBarWidth
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	fmt.Fprintf(os.Stderr, "\r\x1b[K[%s%s] %d/%d collections", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), p.done, p.total)
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
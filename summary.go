@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Summary -
+type Summary struct {
+	Collections  int `json:"collections"`
+	Requests     int `json:"requests"`
+	Folders      int `json:"folders"`
+	Environments int `json:"environments"`
+	Errors       int `json:"errors"`
+	// Files written for the collections, listed for -clean to remove on the
+	// next run
+	Written []string `json:"-"`
+}
+
+// Add counts what other counted on top of the summary.
+func (s *Summary) Add(other *Summary) {
+	s.Collections += other.Collections
+	s.Requests += other.Requests
+	s.Folders += other.Folders
+	s.Environments += other.Environments
+	s.Errors += other.Errors
+}
+
+// Print writes the summary as a line of text, or as a JSON object for
+// scripts to consume.
+func (s *Summary) Print(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(s)
+	}
+
+	_, err := fmt.Fprintf(w, "Converted %d collections with %d requests in %d folders and %d environments, %d errors\n",
+		s.Collections, s.Requests, s.Folders, s.Environments, s.Errors)
+	return err
+}
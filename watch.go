@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often -watch looks for changed files. Changes are
+// only converted once a look found nothing new, so that a file saved in
+// several quick writes is converted once.
+const watchInterval = 500 * time.Millisecond
+
+// fileState is what tells a changed file apart.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watch converts the collection and environment files again whenever they
+// change, until the process is stopped.
+func watch(collectionsDir, environmentsDir, collectionsSubdir, environmentsSubdir string, opts *Options) {
+	var dirs []string
+	for _, dir := range []string{collectionsDir, environmentsDir} {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	logf(levelWarn, "Watching %s for changes, press Ctrl+C to stop", strings.Join(dirs, " and "))
+
+	collections := fileStates(listCollectionFiles, collectionsDir, opts)
+	environments := fileStates(listEnvironmentFiles, environmentsDir, opts)
+	pendingCollections := map[string]bool{}
+	pendingEnvironments := false
+	for range time.Tick(watchInterval) {
+		currentCollections := fileStates(listCollectionFiles, collectionsDir, opts)
+		currentEnvironments := fileStates(listEnvironmentFiles, environmentsDir, opts)
+		changedCollections := changedFiles(collections, currentCollections)
+		changedEnvironments := changedFiles(environments, currentEnvironments)
+		collections, environments = currentCollections, currentEnvironments
+
+		// Wait for the writes to settle
+		if len(changedCollections) > 0 || len(changedEnvironments) > 0 {
+			for _, fileName := range changedCollections {
+				pendingCollections[fileName] = true
+			}
+			pendingEnvironments = pendingEnvironments || len(changedEnvironments) > 0
+			continue
+		}
+		if len(pendingCollections) == 0 && !pendingEnvironments {
+			continue
+		}
+
+		// Convert the changed collections only, and all environments as they
+		// may share output files
+		summary := &Summary{}
+		if pendingEnvironments && opts.DefinedVariables != nil {
+			opts.DefinedVariables = environmentVariableNames(sortedFileNames(environments))
+		}
+		if len(pendingCollections) > 0 {
+			summary = convertCollectionFiles(sortedFileNames(pendingCollections), collectionsDir, collectionsSubdir, opts)
+		}
+		if pendingEnvironments {
+			convertEnvironments(sortedFileNames(environments), environmentsSubdir, opts, summary)
+		}
+		if err := summary.Print(reportOutput, opts.JSONSummary); err != nil {
+			logErrorf("Error printing summary: %v", err)
+		}
+		if opts.StdoutManifest {
+			if err := generated.print(os.Stdout); err != nil {
+				logErrorf("Error printing manifest: %v", err)
+			}
+		}
+		pendingCollections = map[string]bool{}
+		pendingEnvironments = false
+	}
+}
+
+// fileStates returns the state of the files list finds in dir, or nil after
+// printing why they could not be listed.
+func fileStates(list func(string, *Options) ([]string, error), dir string, opts *Options) map[string]fileState {
+	fileNames, err := list(dir, opts)
+	if err != nil {
+		logErrorf("Error reading %s: %v", dir, err)
+		return nil
+	}
+
+	states := map[string]fileState{}
+	for _, fileName := range fileNames {
+		if info, err := os.Stat(fileName); err == nil {
+			states[fileName] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return states
+}
+
+// changedFiles returns the files of current that are new or changed since
+// previous. Files that could not be listed are not taken for new.
+func changedFiles(previous, current map[string]fileState) []string {
+	if previous == nil {
+		return nil
+	}
+	var changed []string
+	for fileName, state := range current {
+		if previousState, ok := previous[fileName]; !ok || previousState != state {
+			changed = append(changed, fileName)
+		}
+	}
+	return changed
+}
+
+// sortedFileNames returns the file names keying files in order.
+func sortedFileNames[V any](files map[string]V) []string {
+	fileNames := make([]string, 0, len(files))
+	for fileName := range files {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	return fileNames
+}